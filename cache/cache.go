@@ -1,37 +1,58 @@
 package cache
 
 import (
-	"container/list"
+	"fmt"
 	"sync"
 	"time"
 )
 
-// Cache is an abstract raw struct used for LIFO-like LRU cache with ttl.
+// Base is an abstract raw struct used for LIFO-like LRU cache with ttl and keys of type K.
 // As it provides no value access interface, it should be extended depending on what's needed.
 // All exported methods should be thread-safe and use internal mutex.
-// See LRUCache for example implementation.
-type Cache struct {
+// See LRU for example implementation.
+type Base[K comparable] struct {
 	cfg Config
 
-	// Maintain both valueMap and valuesList in sync.
+	// Maintain both valueMap and index in sync.
 	// valueMap is used as a storage for key->list
-	// valuesList is used to keep it in TTL order so that we can expire them in same order.
-	mu         sync.RWMutex
-	valueMap   map[string]interface{}
-	valuesList *list.List
-	janitor    *janitor
+	// index is used to keep it in TTL order so that we can expire them in same order.
+	mu       sync.RWMutex
+	valueMap map[K]interface{}
+	index    expirationIndex[K]
+	janitor  *janitor
+	weigher  func(K, interface{}) int64
+	weight   int64
+	policy   Policy[K]
 
 	muHandler      sync.RWMutex
-	onValueEvicted EvictionHandler
+	onValueEvicted func(K, interface{})
+	handlers       []evictionHandlerEntry[K]
+	handlerSeq     uint64
 
-	deleteHandler DeleteHandler
+	deleteHandler DeleteHandler[K]
+
+	stats   statsCounters
+	metrics *metrics
 }
 
+// Cache is an untyped Base with string keys.
+type Cache = Base[string]
+
 // Config holds settable config for cache.
 type Config struct {
 	TTL             time.Duration
 	CleanupInterval time.Duration
 	Capacity        int
+	NegativeTTL     time.Duration
+	MetricsName     string
+	MaxWeight       int64
+	StaleGrace      time.Duration
+	MaxPerKey       int
+
+	// weigher holds func(K, interface{}) int64 matching cache key type.
+	weigher interface{}
+	// policy holds func(Config) Policy[K] matching cache key type.
+	policy interface{}
 }
 
 var DefaultConfig = Config{
@@ -59,34 +80,102 @@ func WithCapacity(c int) Option {
 	}
 }
 
+// WithNegativeTTL enables caching of loader errors in GetOrLoad for given ttl.
+func WithNegativeTTL(ttl time.Duration) Option {
+	return func(config *Config) {
+		config.NegativeTTL = ttl
+	}
+}
+
+// WithMetrics enables recording of cache statistics as OpenCensus measures tagged with given cache name.
+// See DefaultViews.
+func WithMetrics(name string) Option {
+	return func(config *Config) {
+		config.MetricsName = name
+	}
+}
+
+// WithWeigher sets a function used to compute weight of each value in cache with string keys.
+// By default each value weighs 1. See WithMaxWeight.
+func WithWeigher(f func(key string, val interface{}) int64) Option {
+	return WithTypedWeigher(f)
+}
+
+// WithTypedWeigher sets a function used to compute weight of each value in cache with keys of type K.
+// Cache panics on init if K doesn't match its key type.
+func WithTypedWeigher[K comparable](f func(key K, val interface{}) int64) Option {
+	return func(config *Config) {
+		config.weigher = f
+	}
+}
+
+// WithMaxWeight sets maximum total weight of values in cache. Values are evicted according to policy when exceeded.
+func WithMaxWeight(w int64) Option {
+	return func(config *Config) {
+		config.MaxWeight = w
+	}
+}
+
+// WithStaleWhileRevalidate makes LRU values expire softly. For grace period after their ttl has passed, values are
+// still returned while a single background refresh runs through refresher (see LRU.SetRefresher).
+// Values are removed for good once grace period is over.
+func WithStaleWhileRevalidate(grace time.Duration) Option {
+	return func(config *Config) {
+		config.StaleGrace = grace
+	}
+}
+
+// WithMaxPerKey sets maximum number of values kept for a single key in LRUSet.
+// Values of that key closest to expiring are evicted when exceeded.
+func WithMaxPerKey(n int) Option {
+	return func(config *Config) {
+		config.MaxPerKey = n
+	}
+}
+
 // Item is used as a single element in cache.
 type Item struct {
-	object            interface{}
-	expiration        int64
-	ttl               time.Duration
-	valuesListElement *list.Element
+	object     interface{}
+	expiration int64
+	ttl        time.Duration
+	weight     int64
+
+	// Position in expiration index.
+	index int
+	seq   uint64
+	// Position in expiration order of values of a key, only used by LRUSet with MaxPerKey.
+	keyIndex int
 }
 
-type valuesItem struct {
-	key  string
+type valuesItem[K comparable] struct {
+	key  K
 	item *Item
 }
 
-type keyValue struct {
-	key   string
-	value interface{}
+type keyValue[K comparable] struct {
+	key    K
+	value  interface{}
+	reason EvictionReason
 }
 
-// DeleteHandler is a function that should be provided in an implementation embedding Cache struct.
+type evictionHandlerEntry[K comparable] struct {
+	id uint64
+	f  func(K, interface{}, EvictionReason)
+}
+
+// DeleteHandler is a function that should be provided in an implementation embedding Base struct.
 // It is especially useful when dealing with LIFO/FIFO stack-like objects.
 // For standard KV storage there is a defaultDeleteHandler that should be enough.
-type DeleteHandler func(*valuesItem) *keyValue
+type DeleteHandler[K comparable] func(*valuesItem[K]) *keyValue[K]
 
 // EvictionHandler is a callback function called whenever eviction happens.
 type EvictionHandler func(string, interface{})
 
+// ReasonEvictionHandler is a callback function called whenever eviction happens along with the reason of eviction.
+type ReasonEvictionHandler func(string, interface{}, EvictionReason)
+
 // Init initializes cache struct fields and starts janitor process.
-func (c *Cache) Init(deleteHandler DeleteHandler, opts ...Option) {
+func (c *Base[K]) Init(deleteHandler DeleteHandler[K], opts ...Option) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -107,23 +196,46 @@ func (c *Cache) Init(deleteHandler DeleteHandler, opts ...Option) {
 	}
 
 	c.deleteHandler = deleteHandler
-	c.valueMap = make(map[string]interface{})
-	c.valuesList = list.New()
+
+	if cfg.weigher != nil {
+		weigher, ok := cfg.weigher.(func(K, interface{}) int64)
+		if !ok {
+			panic(fmt.Sprintf("cache: weigher %T doesn't match cache key type", cfg.weigher))
+		}
+
+		c.weigher = weigher
+	}
+
+	if cfg.policy != nil {
+		newPolicy, ok := cfg.policy.(func(Config) Policy[K])
+		if !ok {
+			panic(fmt.Sprintf("cache: policy %T doesn't match cache key type", cfg.policy))
+		}
+
+		c.policy = newPolicy(cfg)
+	}
+
+	if cfg.MetricsName != "" {
+		c.metrics = newMetrics(cfg.MetricsName)
+	}
+
+	c.valueMap = make(map[K]interface{})
+	c.index = newHeapIndex[K]()
 	c.janitor = &janitor{
 		interval: cfg.CleanupInterval,
 		stop:     make(chan struct{}),
 	}
 
-	go c.janitor.Run(c)
+	go c.janitor.Run(c.DeleteExpired)
 }
 
 // Config returns a copy of config struct.
-func (c *Cache) Config() Config {
+func (c *Base[K]) Config() Config {
 	return c.cfg
 }
 
 // StopJanitor is meant to be called when cache is no longer needed to avoid leaking goroutine.
-func (c *Cache) StopJanitor() {
+func (c *Base[K]) StopJanitor() {
 	c.mu.Lock()
 	if c.janitor != nil {
 		close(c.janitor.stop)
@@ -133,27 +245,58 @@ func (c *Cache) StopJanitor() {
 }
 
 // Len returns cache length.
-func (c *Cache) Len() int {
+func (c *Base[K]) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.index.Len()
+}
+
+// Weight returns total weight of values in cache.
+func (c *Base[K]) Weight() int64 {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	return c.valuesList.Len()
+	return c.weight
 }
 
 // OnValueEvicted sets an (optional) function that is called with the key and value when value is evicted from the cache.
 // Set to nil to disable.
-func (c *Cache) OnValueEvicted(f EvictionHandler) {
+func (c *Base[K]) OnValueEvicted(f func(K, interface{})) {
 	c.muHandler.Lock()
 	c.onValueEvicted = f
 	c.muHandler.Unlock()
 }
 
+// AddEvictionHandler registers a function that is called with the key, value and reason whenever value is evicted
+// from the cache. Multiple handlers can be registered, they are called in registration order.
+// Returns a function that unregisters the handler.
+func (c *Base[K]) AddEvictionHandler(f func(K, interface{}, EvictionReason)) (remove func()) {
+	c.muHandler.Lock()
+	c.handlerSeq++
+	id := c.handlerSeq
+	c.handlers = append(c.handlers, evictionHandlerEntry[K]{id: id, f: f})
+	c.muHandler.Unlock()
+
+	return func() {
+		c.muHandler.Lock()
+		defer c.muHandler.Unlock()
+
+		for i, h := range c.handlers {
+			if h.id == id {
+				c.handlers = append(c.handlers[:i:i], c.handlers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
 // DeleteOne deletes one element that is closest to expiring. Returns true if list was not empty.
 // Calls onValueEvicted.
-func (c *Cache) DeleteLRU() bool {
+func (c *Base[K]) DeleteLRU() bool {
 	c.mu.Lock()
 
-	evicted := c.deleteLRU()
+	evicted := c.deleteLRU(EvictionReasonCapacity)
 
 	c.mu.Unlock()
 
@@ -166,44 +309,46 @@ func (c *Cache) DeleteLRU() bool {
 	return false
 }
 
-func (c *Cache) deleteLRU() *keyValue {
-	e := c.valuesList.Front()
-	if e == nil {
+func (c *Base[K]) deleteLRU(reason EvictionReason) *keyValue[K] {
+	vi := c.index.Front()
+	if vi == nil {
 		return nil
 	}
 
-	return c.delete(e)
+	return c.delete(vi.item, reason)
 }
 
 // Flush deletes all elements in cache.
-func (c *Cache) Flush() {
+func (c *Base[K]) Flush() {
 	c.DeleteByTime(0)
 }
 
 // DeleteExpired deletes items by checking their expiration against current time. Calls onValueEvicted.
-func (c *Cache) DeleteExpired() {
+func (c *Base[K]) DeleteExpired() {
 	c.DeleteByTime(time.Now().UnixNano())
 }
 
 // DeleteByTime deletes items by checking their expiration against passed now value. If now == 0, deletes all items.
 // Calls onValueEvicted.
-func (c *Cache) DeleteByTime(now int64) {
-	var evictedValues []*keyValue
+func (c *Base[K]) DeleteByTime(now int64) {
+	var evictedValues []*keyValue[K]
 
-	c.mu.Lock()
+	reason := EvictionReasonExpired
+	if now == 0 {
+		reason = EvictionReasonFlushed
+	}
 
-	// Iterate through valuesList (list of valuesListItem) and delete underlying cacheItem if it has expired.
-	for e := c.valuesList.Front(); e != nil; {
-		nextE := e.Next()
+	c.mu.Lock()
 
-		valueEvicted := c.deleteValue(e, now)
-		if valueEvicted == nil {
+	// Iterate through index in expiration order and delete underlying cacheItem if it has expired.
+	for vi := c.index.Front(); vi != nil; vi = c.index.Front() {
+		if now != 0 && vi.item.expiration > now {
 			break
 		}
 
-		e = nextE
-
-		evictedValues = append(evictedValues, valueEvicted)
+		if valueEvicted := c.delete(vi.item, reason); valueEvicted != nil {
+			evictedValues = append(evictedValues, valueEvicted)
+		}
 	}
 
 	c.mu.Unlock()
@@ -211,92 +356,111 @@ func (c *Cache) DeleteByTime(now int64) {
 	c.handleEviction(evictedValues...)
 }
 
-func (c *Cache) defaultDeleteHandler(item *valuesItem) *keyValue {
+func (c *Base[K]) defaultDeleteHandler(item *valuesItem[K]) *keyValue[K] {
 	delete(c.valueMap, item.key)
-	return &keyValue{key: item.key, value: item.item.object}
+	return &keyValue[K]{key: item.key, value: item.item.object}
 }
 
-func (c *Cache) delete(e *list.Element) *keyValue {
-	return c.deleteValue(e, 0)
-}
-
-func (c *Cache) handleEviction(evictedValues ...*keyValue) {
+func (c *Base[K]) handleEviction(evictedValues ...*keyValue[K]) {
 	c.muHandler.RLock()
 
-	if c.onValueEvicted != nil {
-		for _, v := range evictedValues {
+	for _, v := range evictedValues {
+		if c.onValueEvicted != nil {
 			c.onValueEvicted(v.key, v.value)
 		}
+
+		for _, h := range c.handlers {
+			h.f(v.key, v.value, v.reason)
+		}
 	}
 
 	c.muHandler.RUnlock()
 }
 
-// deleteValue deletes exactly one element from valuesList. If now != 0, it is checked against expiration time.
+// delete deletes exactly one item from index.
 // Note: doesn't call onValueEvicted. Returns evicted keyValue.
-func (c *Cache) deleteValue(e *list.Element, now int64) (valueEvicted *keyValue) {
-	item := e.Value.(*valuesItem)
-
-	if now != 0 && item.item.expiration > now {
+func (c *Base[K]) delete(item *Item, reason EvictionReason) (valueEvicted *keyValue[K]) {
+	vi := c.index.Remove(item)
+	if vi == nil {
 		return
 	}
 
-	if _, ok := c.valueMap[item.key]; ok {
-		valueEvicted = c.deleteHandler(item)
-	}
+	c.weight -= item.weight
+	c.recordSize(-1)
 
-	c.valuesList.Remove(e)
+	if c.policy != nil {
+		c.policy.OnRemove(item)
+	}
 
-	return
-}
+	if _, ok := c.valueMap[vi.key]; ok {
+		valueEvicted = c.deleteHandler(vi)
+	}
 
-func (c *Cache) checkLength() {
-	// If we are over the capacity, delete one closest to expiring.
-	if c.cfg.Capacity > 0 {
-		for c.valuesList.Len() > c.cfg.Capacity {
-			c.deleteLRU()
-		}
+	if valueEvicted != nil {
+		valueEvicted.reason = reason
+		c.recordEviction(reason)
 	}
-}
 
-func (c *Cache) add(vi *valuesItem) *list.Element {
-	exp := vi.item.expiration
+	return
+}
 
-	var at *list.Element
+// checkLength deletes items chosen by policy (or closest to expiring) while cache is over the capacity or max weight.
+// Returns evicted keyValues.
+func (c *Base[K]) checkLength() (evictedValues []*keyValue[K]) {
+	for c.overCapacity() {
+		var valueEvicted *keyValue[K]
 
-	for at = c.valuesList.Front(); at != nil; {
-		if at.Value.(*valuesItem).item.expiration > exp {
-			break
+		if victim := c.victim(); victim != nil {
+			valueEvicted = c.delete(victim, EvictionReasonCapacity)
+		} else {
+			valueEvicted = c.deleteLRU(EvictionReasonCapacity)
 		}
 
-		at = at.Next()
+		if valueEvicted != nil {
+			evictedValues = append(evictedValues, valueEvicted)
+		}
 	}
 
-	if at != nil {
-		return c.valuesList.InsertBefore(vi, at)
+	return
+}
+
+func (c *Base[K]) victim() *Item {
+	if c.policy == nil {
+		return nil
 	}
 
-	return c.valuesList.PushBack(vi)
+	return c.policy.Victim()
 }
 
-func (c *Cache) sortMove(ele *list.Element) {
-	exp := ele.Value.(*valuesItem).item.expiration
+func (c *Base[K]) overCapacity() bool {
+	return (c.cfg.Capacity > 0 && c.index.Len() > c.cfg.Capacity) ||
+		(c.cfg.MaxWeight > 0 && c.weight > c.cfg.MaxWeight)
+}
 
-	var at *list.Element
+func (c *Base[K]) add(vi *valuesItem[K]) {
+	vi.item.weight = 1
+	if c.weigher != nil {
+		vi.item.weight = c.weigher(vi.key, vi.item.object)
+	}
 
-	for at = c.valuesList.Front(); at != nil; {
-		if at.Value.(*valuesItem).item.expiration > exp {
-			break
-		}
+	c.weight += vi.item.weight
+	c.index.Push(vi)
+	c.recordSize(1)
 
-		at = at.Next()
+	if c.policy != nil {
+		c.policy.OnAdd(vi.key, vi.item)
 	}
+}
 
-	if at != nil {
-		c.valuesList.MoveBefore(ele, at)
-	}
+func (c *Base[K]) sortMove(item *Item) {
+	c.index.Fix(item)
+}
 
-	c.valuesList.MoveToBack(ele)
+// access notifies policy that item at given key was used.
+func (c *Base[K]) access(key K, item *Item) {
+	if c.policy != nil {
+		c.policy.OnAccess(key, item)
+	}
 }
 
 type janitor struct {
@@ -304,13 +468,13 @@ type janitor struct {
 	stop     chan struct{}
 }
 
-func (j *janitor) Run(cache *Cache) {
+func (j *janitor) Run(deleteExpired func()) {
 	ticker := time.NewTicker(j.interval)
 
 	for {
 		select {
 		case <-ticker.C:
-			cache.DeleteExpired()
+			deleteExpired()
 		case <-j.stop:
 			ticker.Stop()
 			return
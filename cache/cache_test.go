@@ -2,6 +2,7 @@ package cache
 
 import (
 	"testing"
+	"time"
 
 	. "github.com/smartystreets/goconvey/convey"
 	"github.com/stretchr/testify/mock"
@@ -15,6 +16,20 @@ func (m *MockHandler) OnValueEvicted(key string, val interface{}) {
 	m.Called(key, val)
 }
 
+// backKey returns key of an item that is the last one to expire.
+func backKey[K comparable](c *Base[K]) K {
+	var back *valuesItem[K]
+
+	for _, vi := range c.index.(*heapIndex[K]).items {
+		if back == nil || vi.item.expiration > back.item.expiration ||
+			(vi.item.expiration == back.item.expiration && vi.item.seq > back.item.seq) {
+			back = vi
+		}
+	}
+
+	return back.key
+}
+
 func TestCache(t *testing.T) {
 	Convey("Given empty cache struct", t, func() {
 		c := new(Cache)
@@ -22,16 +37,18 @@ func TestCache(t *testing.T) {
 		Convey("Init sets default delete handler and starts janitor", func() {
 			c.Init(nil)
 			So(c.janitor, ShouldNotBeNil)
-			So(c.deleteHandler, ShouldEqual, c.defaultDeleteHandler)
+			c.valueMap["key"] = new(Item)
+			So(c.deleteHandler(&valuesItem[string]{key: "key", item: new(Item)}), ShouldNotBeNil)
+			So(c.valueMap, ShouldBeEmpty)
 
 			Convey("and cannot be called twice", func() {
 				So(func() { c.Init(nil) }, ShouldPanic)
 			})
 			Convey("delete returns false when delete handler returns nil", func() {
-				c.deleteHandler = func(item *valuesItem) *keyValue {
+				c.deleteHandler = func(item *valuesItem[string]) *keyValue[string] {
 					return nil
 				}
-				c.valuesList.PushBack(&valuesItem{key: "key", item: new(Item)})
+				c.index.Push(&valuesItem[string]{key: "key", item: new(Item)})
 				So(c.DeleteLRU(), ShouldBeFalse)
 			})
 
@@ -49,3 +66,63 @@ func TestCache(t *testing.T) {
 
 	})
 }
+
+func TestEvictionHandlers(t *testing.T) {
+	Convey("Given new LRU cache with eviction handlers", t, func() {
+		type eviction struct {
+			key    string
+			reason EvictionReason
+		}
+
+		c := NewLRUCache(false, WithCapacity(2))
+		c.StopJanitor()
+
+		var first, second []eviction
+
+		c.AddEvictionHandler(func(key string, val interface{}, reason EvictionReason) {
+			first = append(first, eviction{key, reason})
+		})
+		removeSecond := c.AddEvictionHandler(func(key string, val interface{}, reason EvictionReason) {
+			second = append(second, eviction{key, reason})
+		})
+
+		Convey("all handlers receive eviction reason", func() {
+			c.Set("key1", "value1")
+			c.Set("key1", "value2")
+			c.Set("key2", "value2")
+			c.Set("key3", "value3")
+			c.Delete("key2")
+			c.SetTTL("key4", "value4", time.Nanosecond)
+			time.Sleep(time.Millisecond)
+			c.DeleteExpired()
+			c.Flush()
+
+			expected := []eviction{
+				{"key1", EvictionReasonReplaced},
+				{"key1", EvictionReasonCapacity},
+				{"key2", EvictionReasonDeleted},
+				{"key4", EvictionReasonExpired},
+				{"key3", EvictionReasonFlushed},
+			}
+			So(first, ShouldResemble, expected)
+			So(second, ShouldResemble, expected)
+		})
+		Convey("removed handler is no longer called", func() {
+			removeSecond()
+			removeSecond()
+			c.Set("key1", "value1")
+			c.Delete("key1")
+			So(first, ShouldHaveLength, 1)
+			So(second, ShouldBeEmpty)
+		})
+		Convey("legacy handler is called along with registered ones", func() {
+			mh := new(MockHandler)
+			mh.On("OnValueEvicted", "key1", "value1").Once()
+			c.OnValueEvicted(mh.OnValueEvicted)
+			c.Set("key1", "value1")
+			c.Delete("key1")
+			So(first, ShouldHaveLength, 1)
+			mh.AssertExpectations(t)
+		})
+	})
+}
@@ -0,0 +1,178 @@
+package cache
+
+import (
+	"container/heap"
+)
+
+// expirationIndex keeps cache items ordered by expiration time (and insertion order for equal expirations).
+type expirationIndex[K comparable] interface {
+	// Len returns number of items in index.
+	Len() int
+	// Push adds new item to index.
+	Push(vi *valuesItem[K])
+	// Fix reorders item after its expiration has changed.
+	Fix(item *Item)
+	// Remove deletes item from index. Returns nil if item is not a part of index.
+	Remove(item *Item) *valuesItem[K]
+	// Front returns item that is closest to expiring or nil if index is empty.
+	Front() *valuesItem[K]
+	// Range calls f for each item in index in unspecified order.
+	Range(f func(vi *valuesItem[K]))
+}
+
+// heapIndex is a binary min-heap based expirationIndex with O(log n) Push, Fix and Remove.
+type heapIndex[K comparable] struct {
+	items expirationHeap[K]
+	seq   uint64
+}
+
+func newHeapIndex[K comparable]() *heapIndex[K] {
+	return &heapIndex[K]{}
+}
+
+func (h *heapIndex[K]) Len() int {
+	return len(h.items)
+}
+
+func (h *heapIndex[K]) nextSeq() uint64 {
+	h.seq++
+	return h.seq
+}
+
+func (h *heapIndex[K]) Push(vi *valuesItem[K]) {
+	vi.item.seq = h.nextSeq()
+	heap.Push(&h.items, vi)
+}
+
+func (h *heapIndex[K]) Fix(item *Item) {
+	if !h.contains(item) {
+		return
+	}
+
+	item.seq = h.nextSeq()
+	heap.Fix(&h.items, item.index)
+}
+
+func (h *heapIndex[K]) Remove(item *Item) *valuesItem[K] {
+	if !h.contains(item) {
+		return nil
+	}
+
+	return heap.Remove(&h.items, item.index).(*valuesItem[K])
+}
+
+func (h *heapIndex[K]) Front() *valuesItem[K] {
+	if len(h.items) == 0 {
+		return nil
+	}
+
+	return h.items[0]
+}
+
+func (h *heapIndex[K]) Range(f func(vi *valuesItem[K])) {
+	for _, vi := range h.items {
+		f(vi)
+	}
+}
+
+func (h *heapIndex[K]) contains(item *Item) bool {
+	return item.index >= 0 && item.index < len(h.items) && h.items[item.index].item == item
+}
+
+// expiresBefore returns true if a is closer to expiring than b.
+func expiresBefore(a, b *Item) bool {
+	if a.expiration == b.expiration {
+		return a.seq < b.seq
+	}
+
+	return a.expiration < b.expiration
+}
+
+// expirationHeap implements heap.Interface.
+type expirationHeap[K comparable] []*valuesItem[K]
+
+func (h expirationHeap[K]) Len() int {
+	return len(h)
+}
+
+func (h expirationHeap[K]) Less(i, j int) bool {
+	return expiresBefore(h[i].item, h[j].item)
+}
+
+func (h expirationHeap[K]) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].item.index = i
+	h[j].item.index = j
+}
+
+func (h *expirationHeap[K]) Push(x interface{}) {
+	vi := x.(*valuesItem[K])
+	vi.item.index = len(*h)
+	*h = append(*h, vi)
+}
+
+func (h *expirationHeap[K]) Pop() interface{} {
+	old := *h
+	n := len(old)
+	vi := old[n-1]
+	old[n-1] = nil
+	vi.item.index = -1
+	*h = old[:n-1]
+
+	return vi
+}
+
+// keyExpirationHeap orders items of a single key by expiration. It implements heap.Interface using keyIndex
+// of items so that they can be a part of expiration index at the same time.
+type keyExpirationHeap []*Item
+
+func (h keyExpirationHeap) Len() int {
+	return len(h)
+}
+
+func (h keyExpirationHeap) Less(i, j int) bool {
+	return expiresBefore(h[i], h[j])
+}
+
+func (h keyExpirationHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].keyIndex = i
+	h[j].keyIndex = j
+}
+
+func (h *keyExpirationHeap) Push(x interface{}) {
+	item := x.(*Item)
+	item.keyIndex = len(*h)
+	*h = append(*h, item)
+}
+
+func (h *keyExpirationHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	item.keyIndex = -1
+	*h = old[:n-1]
+
+	return item
+}
+
+func (h keyExpirationHeap) contains(item *Item) bool {
+	return item.keyIndex >= 0 && item.keyIndex < len(h) && h[item.keyIndex] == item
+}
+
+// oldest returns item closest to expiring other than skip or nil if there is none.
+func (h keyExpirationHeap) oldest(skip *Item) *Item {
+	switch {
+	case len(h) == 0:
+		return nil
+	case h[0] != skip:
+		return h[0]
+	case len(h) == 1:
+		return nil
+	case len(h) == 2 || expiresBefore(h[1], h[2]):
+		return h[1]
+	}
+
+	return h[2]
+}
@@ -0,0 +1,159 @@
+package cache
+
+import (
+	"container/list"
+	"math/rand"
+	"strconv"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// listIndex is a sorted linked list based expirationIndex with O(n) Push and Fix.
+// It is kept as a baseline for benchmarks.
+type listIndex[K comparable] struct {
+	l        *list.List
+	elements map[*Item]*list.Element
+}
+
+func newListIndex[K comparable]() *listIndex[K] {
+	return &listIndex[K]{l: list.New(), elements: make(map[*Item]*list.Element)}
+}
+
+func (li *listIndex[K]) Len() int {
+	return li.l.Len()
+}
+
+func (li *listIndex[K]) Push(vi *valuesItem[K]) {
+	var at *list.Element
+
+	for at = li.l.Front(); at != nil; at = at.Next() {
+		if at.Value.(*valuesItem[K]).item.expiration > vi.item.expiration {
+			break
+		}
+	}
+
+	if at != nil {
+		li.elements[vi.item] = li.l.InsertBefore(vi, at)
+	} else {
+		li.elements[vi.item] = li.l.PushBack(vi)
+	}
+}
+
+func (li *listIndex[K]) Fix(item *Item) {
+	vi := li.Remove(item)
+	if vi != nil {
+		li.Push(vi)
+	}
+}
+
+func (li *listIndex[K]) Remove(item *Item) *valuesItem[K] {
+	e, ok := li.elements[item]
+	if !ok {
+		return nil
+	}
+
+	delete(li.elements, item)
+
+	return li.l.Remove(e).(*valuesItem[K])
+}
+
+func (li *listIndex[K]) Front() *valuesItem[K] {
+	if e := li.l.Front(); e != nil {
+		return e.Value.(*valuesItem[K])
+	}
+
+	return nil
+}
+
+func (li *listIndex[K]) Range(f func(vi *valuesItem[K])) {
+	for e := li.l.Front(); e != nil; e = e.Next() {
+		f(e.Value.(*valuesItem[K]))
+	}
+}
+
+func TestHeapIndex(t *testing.T) {
+	Convey("Given empty heap index", t, func() {
+		h := newHeapIndex[string]()
+		newItem := func(key string, exp int64) *valuesItem[string] {
+			return &valuesItem[string]{key: key, item: &Item{expiration: exp}}
+		}
+
+		Convey("Front returns nil", func() {
+			So(h.Front(), ShouldBeNil)
+		})
+		Convey("Remove of unknown item returns nil", func() {
+			So(h.Remove(new(Item)), ShouldBeNil)
+		})
+		Convey("with some items", func() {
+			items := []*valuesItem[string]{newItem("a", 3), newItem("b", 1), newItem("c", 2), newItem("d", 1)}
+			for _, vi := range items {
+				h.Push(vi)
+			}
+
+			So(h.Len(), ShouldEqual, 4)
+
+			Convey("Front returns items in expiration then insertion order", func() {
+				var keys []string
+				for vi := h.Front(); vi != nil; vi = h.Front() {
+					keys = append(keys, vi.key)
+					h.Remove(vi.item)
+				}
+				So(keys, ShouldResemble, []string{"b", "d", "c", "a"})
+			})
+			Convey("Fix reorders item after expiration change", func() {
+				items[0].item.expiration = 0
+				h.Fix(items[0].item)
+				So(h.Front().key, ShouldEqual, "a")
+			})
+			Convey("Fix moves item behind others with equal expiration", func() {
+				h.Fix(items[1].item)
+				So(h.Front().key, ShouldEqual, "d")
+			})
+			Convey("Remove deletes item only once", func() {
+				So(h.Remove(items[2].item), ShouldEqual, items[2])
+				So(h.Remove(items[2].item), ShouldBeNil)
+				So(h.Len(), ShouldEqual, 3)
+			})
+		})
+	})
+}
+
+func benchmarkCacheSet(b *testing.B, newIndex func() expirationIndex[string], ttl func(i int) time.Duration) {
+	const size = 20000
+
+	c := NewLRUCache(false, WithCapacity(size))
+	defer c.StopJanitor()
+
+	c.index = newIndex()
+
+	for i := 0; i < size; i++ {
+		c.SetTTL(strconv.Itoa(i), i, ttl(i))
+	}
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		c.SetTTL(strconv.Itoa(i%(2*size)), i, ttl(i))
+	}
+}
+
+func BenchmarkCacheSet(b *testing.B) {
+	indexes := map[string]func() expirationIndex[string]{
+		"list": func() expirationIndex[string] { return newListIndex[string]() },
+		"heap": func() expirationIndex[string] { return newHeapIndex[string]() },
+	}
+	ttls := map[string]func(i int) time.Duration{
+		"uniform": func(i int) time.Duration { return time.Minute },
+		"mixed":   func(i int) time.Duration { return time.Duration(1+rand.Intn(3600)) * time.Second }, // nolint: gosec
+	}
+
+	for indexName, newIndex := range indexes {
+		for ttlName, ttl := range ttls {
+			b.Run(indexName+"/"+ttlName, func(b *testing.B) {
+				benchmarkCacheSet(b, newIndex, ttl)
+			})
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Syncano/pkg-go/v2/util"
+)
+
+// ErrLoaderPanic is returned to callers of GetOrLoad whose loader panicked.
+var ErrLoaderPanic = errors.New("cache: loader panicked")
+
+// Loader is a function that computes value for a missing key along with its ttl (0 means default ttl).
+type Loader[V any] func(ctx context.Context) (V, time.Duration, error)
+
+type loadCall[V any] struct {
+	done       chan struct{}
+	cancel     context.CancelFunc
+	refs       int
+	revalidate bool
+
+	val V
+	err error
+}
+
+// detachedContext keeps values of parent context but is never canceled by it.
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (deadline time.Time, ok bool) {
+	return
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
+
+// GetOrLoad returns an item at given key or loads it through loader if it doesn't exist.
+// Concurrent calls for the same key share a single in-flight load. If negative ttl is configured, loader errors
+// are cached and returned for that long. Stale value is returned right away and refreshed through loader in background.
+// Loader runs with a context that is canceled once all callers waiting for it have given up, callers that come
+// after that start a new load. Each caller returns early with its own context error. Loader panic is returned
+// as ErrLoaderPanic.
+func (c *LRU[K, V]) GetOrLoad(ctx context.Context, key K, loader Loader[V]) (V, error) {
+	val, ok, stale := c.lookup(key, c.autoRefresh)
+	c.recordLookup(ok)
+
+	if stale {
+		c.revalidate(key, loader)
+	} else if ok && c.autoRefresh {
+		c.notify(Event[K, V]{Type: EventRefresh, Key: key, Value: val})
+	}
+
+	if ok {
+		return val, nil
+	}
+
+	if err := c.negativeErr(key); err != nil {
+		var zero V
+		return zero, err
+	}
+
+	c.muLoad.Lock()
+
+	call, ok := c.calls[key]
+	if !ok {
+		// Check again as load could have finished in the meantime.
+		if val, ok := c.get(key, false); ok {
+			c.muLoad.Unlock()
+			return val, nil
+		}
+
+		loadCtx, cancel := context.WithCancel(detachedContext{ctx})
+		call = &loadCall[V]{done: make(chan struct{}), cancel: cancel}
+		c.calls[key] = call
+
+		go c.load(loadCtx, key, call, loader)
+	}
+
+	call.refs++
+	c.muLoad.Unlock()
+
+	select {
+	case <-call.done:
+		return call.val, call.err
+	case <-ctx.Done():
+		c.muLoad.Lock()
+		call.refs--
+
+		if call.refs == 0 {
+			call.cancel()
+
+			if c.calls[key] == call {
+				delete(c.calls, key)
+			}
+		}
+
+		c.muLoad.Unlock()
+
+		var zero V
+
+		return zero, ctx.Err()
+	}
+}
+
+func (c *LRU[K, V]) negativeErr(key K) error {
+	if c.negative == nil {
+		return nil
+	}
+
+	err, _ := c.negative.Get(key)
+
+	return err
+}
+
+// revalidate starts a background refresh of stale value at given key through loader or registered refresher
+// unless one is already in progress.
+func (c *LRU[K, V]) revalidate(key K, loader Loader[V]) {
+	c.muLoad.Lock()
+	defer c.muLoad.Unlock()
+
+	if _, ok := c.calls[key]; ok {
+		return
+	}
+
+	if loader == nil {
+		refresher := c.refresher
+		if refresher == nil {
+			return
+		}
+
+		loader = func(ctx context.Context) (V, time.Duration, error) {
+			return refresher(ctx, key)
+		}
+	}
+
+	// Revalidation holds its own reference so that it is not canceled by callers joining and giving up on it.
+	ctx, cancel := context.WithCancel(context.Background())
+	call := &loadCall[V]{done: make(chan struct{}), cancel: cancel, refs: 1, revalidate: true}
+	c.calls[key] = call
+
+	go c.load(ctx, key, call, loader)
+}
+
+func (c *LRU[K, V]) load(ctx context.Context, key K, call *loadCall[V], loader Loader[V]) {
+	defer call.cancel()
+
+	val, ttl, err := runLoader(ctx, loader)
+
+	switch {
+	case err == nil:
+		c.SetTTL(key, val, ttl)
+	case call.revalidate:
+		c.refreshFailed(key, err)
+	case c.negative != nil && !util.IsContextError(err):
+		c.negative.Set(key, err)
+	}
+
+	c.muLoad.Lock()
+	if c.calls[key] == call {
+		delete(c.calls, key)
+	}
+	call.val, call.err = val, err
+	close(call.done)
+	c.muLoad.Unlock()
+}
+
+// runLoader calls loader turning its panic into ErrLoaderPanic so that callers waiting for it are released.
+func runLoader[V any](ctx context.Context, loader Loader[V]) (val V, ttl time.Duration, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			var zero V
+			val, err = zero, fmt.Errorf("%w: %v", ErrLoaderPanic, r)
+		}
+	}()
+
+	return loader(ctx)
+}
+
+func (c *LRU[K, V]) refreshFailed(key K, err error) {
+	c.muLoad.Lock()
+	onError := c.onRefreshError
+	c.muLoad.Unlock()
+
+	if onError != nil {
+		onError(key, err)
+	}
+}
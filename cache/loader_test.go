@@ -0,0 +1,260 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestGetOrLoad(t *testing.T) {
+	Convey("Given new LRU cache", t, func() {
+		c := NewLRUCache(false)
+		errLoad := errors.New("some error")
+
+		Convey("GetOrLoad returns existing value without calling loader", func() {
+			c.Set("key", "value")
+			v, err := c.GetOrLoad(context.Background(), "key", func(ctx context.Context) (interface{}, time.Duration, error) {
+				panic("should not be called")
+			})
+			So(err, ShouldBeNil)
+			So(v, ShouldEqual, "value")
+		})
+		Convey("GetOrLoad stores loaded value with returned ttl", func() {
+			v, err := c.GetOrLoad(context.Background(), "key", func(ctx context.Context) (interface{}, time.Duration, error) {
+				return "value", time.Minute, nil
+			})
+			So(err, ShouldBeNil)
+			So(v, ShouldEqual, "value")
+			So(c.Get("key"), ShouldEqual, "value")
+			So(c.valueMap["key"].(*Item).ttl, ShouldEqual, time.Minute)
+		})
+		Convey("GetOrLoad shares a single load between concurrent callers", func() {
+			var (
+				calls int32
+				wg    sync.WaitGroup
+			)
+
+			release := make(chan struct{})
+			loader := func(ctx context.Context) (interface{}, time.Duration, error) {
+				atomic.AddInt32(&calls, 1)
+				<-release
+				return "value", 0, nil
+			}
+			results := make([]interface{}, 5)
+
+			for i := range results {
+				wg.Add(1)
+
+				go func(i int) {
+					results[i], _ = c.GetOrLoad(context.Background(), "key", loader)
+					wg.Done()
+				}(i)
+			}
+
+			time.Sleep(10 * time.Millisecond)
+			close(release)
+			wg.Wait()
+
+			So(atomic.LoadInt32(&calls), ShouldEqual, 1)
+			for _, r := range results {
+				So(r, ShouldEqual, "value")
+			}
+		})
+		Convey("GetOrLoad doesn't cache errors by default", func() {
+			_, err := c.GetOrLoad(context.Background(), "key", func(ctx context.Context) (interface{}, time.Duration, error) {
+				return nil, 0, errLoad
+			})
+			So(err, ShouldEqual, errLoad)
+			v, err := c.GetOrLoad(context.Background(), "key", func(ctx context.Context) (interface{}, time.Duration, error) {
+				return "value", 0, nil
+			})
+			So(err, ShouldBeNil)
+			So(v, ShouldEqual, "value")
+		})
+		Convey("GetOrLoad returns context error when caller gives up", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			loadCanceled := make(chan struct{})
+
+			go func() {
+				time.Sleep(10 * time.Millisecond)
+				cancel()
+			}()
+
+			_, err := c.GetOrLoad(ctx, "key", func(ctx context.Context) (interface{}, time.Duration, error) {
+				<-ctx.Done()
+				close(loadCanceled)
+				return nil, 0, ctx.Err()
+			})
+			So(err, ShouldEqual, context.Canceled)
+
+			Convey("and cancels loader once all callers are gone", func() {
+				<-loadCanceled
+			})
+		})
+		Convey("GetOrLoad starts a new load after all callers of previous one gave up", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			release := make(chan struct{})
+			errc := make(chan error)
+
+			go func() {
+				_, err := c.GetOrLoad(ctx, "key", func(ctx context.Context) (interface{}, time.Duration, error) {
+					<-release
+					return nil, 0, ctx.Err()
+				})
+				errc <- err
+			}()
+
+			time.Sleep(10 * time.Millisecond)
+			cancel()
+			So(<-errc, ShouldEqual, context.Canceled)
+
+			v, err := c.GetOrLoad(context.Background(), "key", func(ctx context.Context) (interface{}, time.Duration, error) {
+				return "value", 0, nil
+			})
+			So(err, ShouldBeNil)
+			So(v, ShouldEqual, "value")
+
+			close(release)
+		})
+		Convey("GetOrLoad returns error to all callers when loader panics", func() {
+			var wg sync.WaitGroup
+
+			release := make(chan struct{})
+			errs := make([]error, 3)
+
+			for i := range errs {
+				wg.Add(1)
+
+				go func(i int) {
+					_, errs[i] = c.GetOrLoad(context.Background(), "key", func(ctx context.Context) (interface{}, time.Duration, error) {
+						<-release
+						panic("boom")
+					})
+					wg.Done()
+				}(i)
+			}
+
+			time.Sleep(10 * time.Millisecond)
+			close(release)
+			wg.Wait()
+
+			for _, err := range errs {
+				So(errors.Is(err, ErrLoaderPanic), ShouldBeTrue)
+			}
+
+			So(c.calls, ShouldBeEmpty)
+		})
+
+		c.StopJanitor()
+	})
+
+	Convey("Given new LRU cache with negative ttl", t, func() {
+		c := NewLRU[string, int](false, WithNegativeTTL(time.Minute))
+		errLoad := errors.New("some error")
+
+		Convey("GetOrLoad caches loader errors", func() {
+			_, err := c.GetOrLoad(context.Background(), "key", func(ctx context.Context) (int, time.Duration, error) {
+				return 0, 0, errLoad
+			})
+			So(err, ShouldEqual, errLoad)
+			_, err = c.GetOrLoad(context.Background(), "key", func(ctx context.Context) (int, time.Duration, error) {
+				return 1, 0, nil
+			})
+			So(err, ShouldEqual, errLoad)
+		})
+		Convey("GetOrLoad doesn't cache context errors", func() {
+			_, err := c.GetOrLoad(context.Background(), "key", func(ctx context.Context) (int, time.Duration, error) {
+				return 0, 0, context.DeadlineExceeded
+			})
+			So(errors.Is(err, context.DeadlineExceeded), ShouldBeTrue)
+			v, err := c.GetOrLoad(context.Background(), "key", func(ctx context.Context) (int, time.Duration, error) {
+				return 1, 0, nil
+			})
+			So(err, ShouldBeNil)
+			So(v, ShouldEqual, 1)
+		})
+
+		c.StopJanitor()
+		So(c.negative.janitor, ShouldBeNil)
+	})
+}
+
+func TestStaleWhileRevalidate(t *testing.T) {
+	Convey("Given LRU cache with stale while revalidate and stale value", t, func() {
+		const grace = time.Minute
+
+		c := NewLRUCache(true, WithStaleWhileRevalidate(grace))
+		c.Set("key", "old")
+		c.valueMap["key"].(*Item).expiration = time.Now().Add(grace / 2).UnixNano()
+
+		var calls int32
+
+		refreshed := make(chan struct{})
+		release := make(chan struct{})
+		errRefresh := errors.New("some error")
+
+		Convey("Get serves stale value while a single refresh runs in background", func() {
+			c.SetRefresher(func(ctx context.Context, key string) (interface{}, time.Duration, error) {
+				atomic.AddInt32(&calls, 1)
+				<-release
+				return "new", 0, nil
+			}, nil)
+			c.OnValueEvicted(func(key string, val interface{}) {
+				close(refreshed)
+			})
+
+			for i := 0; i < 3; i++ {
+				So(c.Get("key"), ShouldEqual, "old")
+			}
+
+			close(release)
+			<-refreshed
+
+			So(atomic.LoadInt32(&calls), ShouldEqual, 1)
+			So(c.Get("key"), ShouldEqual, "new")
+			So(c.valueMap["key"].(*Item).expiration, ShouldBeGreaterThan, time.Now().Add(c.cfg.TTL).UnixNano())
+		})
+		Convey("refresh failure is reported and stale value is still served", func() {
+			failed := make(chan error, 2)
+
+			c.SetRefresher(func(ctx context.Context, key string) (interface{}, time.Duration, error) {
+				return nil, 0, errRefresh
+			}, func(key string, err error) {
+				failed <- err
+			})
+
+			So(c.Get("key"), ShouldEqual, "old")
+			So(<-failed, ShouldEqual, errRefresh)
+			So(c.Get("key"), ShouldEqual, "old")
+		})
+		Convey("GetOrLoad serves stale value and refreshes it through its loader", func() {
+			v, err := c.GetOrLoad(context.Background(), "key", func(ctx context.Context) (interface{}, time.Duration, error) {
+				defer close(refreshed)
+				return "new", 0, nil
+			})
+			So(err, ShouldBeNil)
+			So(v, ShouldEqual, "old")
+
+			<-refreshed
+			time.Sleep(10 * time.Millisecond)
+			So(c.Get("key"), ShouldEqual, "new")
+		})
+		Convey("Get without refresher serves stale value and doesn't extend it", func() {
+			exp := c.valueMap["key"].(*Item).expiration
+
+			So(c.Get("key"), ShouldEqual, "old")
+			So(c.valueMap["key"].(*Item).expiration, ShouldEqual, exp)
+		})
+		Convey("Get returns nil after grace period is over", func() {
+			c.valueMap["key"].(*Item).expiration = 1
+			So(c.Get("key"), ShouldBeNil)
+		})
+
+		c.StopJanitor()
+	})
+}
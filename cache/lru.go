@@ -1,127 +1,238 @@
 package cache
 
 import (
+	"context"
+	"sync"
 	"time"
 )
 
-// LRUCache describes a struct for caching.
-type LRUCache struct {
-	Cache
+// LRU describes a typed struct for caching.
+type LRU[K comparable, V any] struct {
+	Base[K]
 	autoRefresh bool
+
+	muLoad         sync.Mutex
+	calls          map[K]*loadCall[V]
+	negative       *LRU[K, error]
+	refresher      func(ctx context.Context, key K) (V, time.Duration, error)
+	onRefreshError func(key K, err error)
+
+	muWatch            sync.RWMutex
+	watchers           []*watcher[K, V]
+	removeWatchHandler func()
 }
 
-// NewLRUCache creates and initializes a new cache object.
+// NewLRU creates and initializes a new typed cache object.
 // This one is based on LRU KV with TTL
-func NewLRUCache(autoRefresh bool, opts ...Option) *LRUCache {
-	cache := LRUCache{
-		autoRefresh: autoRefresh,
+func NewLRU[K comparable, V any](autoRefresh bool, opts ...Option) *LRU[K, V] {
+	cache := LRU[K, V]{}
+	cache.init(autoRefresh, opts...)
+
+	return &cache
+}
+
+func (c *LRU[K, V]) init(autoRefresh bool, opts ...Option) {
+	c.autoRefresh = autoRefresh
+	c.calls = make(map[K]*loadCall[V])
+	c.Base.Init(nil, opts...)
+
+	if c.cfg.NegativeTTL > 0 {
+		c.negative = NewLRU[K, error](false,
+			WithTTL(c.cfg.NegativeTTL), WithCapacity(c.cfg.Capacity), WithCleanupInterval(c.cfg.CleanupInterval))
 	}
+}
 
-	cache.Cache.Init(cache.deleteHandler, opts...)
+// StopJanitor is meant to be called when cache is no longer needed to avoid leaking goroutine.
+func (c *LRU[K, V]) StopJanitor() {
+	c.Base.StopJanitor()
 
-	return &cache
+	if c.negative != nil {
+		c.negative.StopJanitor()
+	}
 }
 
-// Get returns an item at given key. It automatically extends the expiration if auto refresh is true. Returns the item or nil.
-func (c *LRUCache) Get(key string) interface{} {
-	return c.get(key, c.autoRefresh)
+// OnValueEvicted sets an (optional) function that is called with the key and value when value is evicted from the cache.
+// Set to nil to disable.
+func (c *LRU[K, V]) OnValueEvicted(f func(K, V)) {
+	if f == nil {
+		c.Base.OnValueEvicted(nil)
+		return
+	}
+
+	c.Base.OnValueEvicted(func(key K, val interface{}) {
+		v, _ := val.(V)
+		f(key, v)
+	})
+}
+
+// AddEvictionHandler registers a function that is called with the key, value and reason whenever value is evicted
+// from the cache. Returns a function that unregisters the handler.
+func (c *LRU[K, V]) AddEvictionHandler(f func(K, V, EvictionReason)) (remove func()) {
+	return c.Base.AddEvictionHandler(func(key K, val interface{}, reason EvictionReason) {
+		v, _ := val.(V)
+		f(key, v, reason)
+	})
+}
+
+// Get returns an item at given key and true if it was found.
+// It automatically extends the expiration if auto refresh is true.
+// With stale while revalidate enabled, stale value is returned and a background refresh is started.
+func (c *LRU[K, V]) Get(key K) (V, bool) {
+	val, ok, stale := c.lookup(key, c.autoRefresh)
+	c.recordLookup(ok)
+
+	if stale {
+		c.revalidate(key, nil)
+	} else if ok && c.autoRefresh {
+		c.notify(Event[K, V]{Type: EventRefresh, Key: key, Value: val})
+	}
+
+	return val, ok
+}
+
+func (c *LRU[K, V]) get(key K, refresh bool) (V, bool) {
+	val, ok, _ := c.lookup(key, refresh)
+	return val, ok
 }
 
-func (c *LRUCache) get(key string, refresh bool) interface{} {
+// lookup returns an item at given key, true if it was found and true if it is past its ttl but within stale grace period.
+// Stale items are never refreshed.
+func (c *LRU[K, V]) lookup(key K, refresh bool) (ret V, found, stale bool) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
 	val, ok := c.valueMap[key]
 	if !ok {
-		return nil
+		return
 	}
 
 	cItem := val.(*Item)
-	if time.Now().UnixNano() > cItem.expiration {
-		return nil
+	now := time.Now().UnixNano()
+
+	if now > cItem.expiration {
+		return
 	}
 
-	if refresh {
-		cItem.expiration = time.Now().Add(cItem.ttl).UnixNano()
-		c.sortMove(cItem.valuesListElement)
+	stale = c.cfg.StaleGrace > 0 && now > cItem.expiration-int64(c.cfg.StaleGrace)
+
+	if refresh && !stale {
+		cItem.expiration = c.expiration(cItem.ttl)
+		c.sortMove(cItem)
 	}
 
-	return cItem.object
+	c.access(key, cItem)
+
+	ret, _ = cItem.object.(V)
+
+	return ret, true, stale
+}
+
+// expiration returns hard expiration time of item with given ttl.
+func (c *LRU[K, V]) expiration(ttl time.Duration) int64 {
+	return time.Now().Add(ttl + c.cfg.StaleGrace).UnixNano()
+}
+
+// SetRefresher registers a function used to refresh stale values in background. See WithStaleWhileRevalidate.
+// onError is an (optional) function called when refresh fails, stale value is kept until its grace period is over.
+func (c *LRU[K, V]) SetRefresher(f func(ctx context.Context, key K) (V, time.Duration, error), onError func(key K, err error)) {
+	c.muLoad.Lock()
+	c.refresher = f
+	c.onRefreshError = onError
+	c.muLoad.Unlock()
 }
 
 // Refresh extends the expiration of given key. Returns true on success.
-func (c *LRUCache) Refresh(key string) bool {
-	return c.get(key, true) != nil
+func (c *LRU[K, V]) Refresh(key K) bool {
+	val, ok, stale := c.lookup(key, true)
+	if ok && !stale {
+		c.notify(Event[K, V]{Type: EventRefresh, Key: key, Value: val})
+	}
+
+	return ok
 }
 
-func (c *LRUCache) set(key string, val interface{}, ttl time.Duration) {
+func (c *LRU[K, V]) set(key K, val V, ttl time.Duration) []*keyValue[K] {
 	if ttl == 0 {
 		ttl = c.cfg.TTL
 	}
 
-	cItem := &Item{object: val, expiration: time.Now().Add(ttl).UnixNano(), ttl: ttl}
-	vi := &valuesItem{key: key, item: cItem}
+	return c.setItem(key, &Item{object: val, expiration: c.expiration(ttl), ttl: ttl})
+}
+
+func (c *LRU[K, V]) setItem(key K, cItem *Item) []*keyValue[K] {
+	vi := &valuesItem[K]{key: key, item: cItem}
 
-	cItem.valuesListElement = c.add(vi)
-	c.checkLength()
+	c.add(vi)
 	c.valueMap[key] = cItem
+
+	return c.checkLength()
 }
 
 // Set assigns a new value to an item at given key.
-func (c *LRUCache) Set(key string, val interface{}) {
+func (c *LRU[K, V]) Set(key K, val V) {
 	c.SetTTL(key, val, 0)
 }
 
-func (c *LRUCache) SetTTL(key string, val interface{}, ttl time.Duration) {
-	var evicted *keyValue
+func (c *LRU[K, V]) SetTTL(key K, val V, ttl time.Duration) {
+	var replaced *keyValue[K]
 
 	c.mu.Lock()
 
 	curVal, ok := c.valueMap[key]
 	if ok {
-		evicted = c.delete(curVal.(*Item).valuesListElement)
+		replaced = c.delete(curVal.(*Item), EvictionReasonReplaced)
 	}
 
-	c.set(key, val, ttl)
+	evicted := c.set(key, val, ttl)
 	c.mu.Unlock()
 
-	if evicted != nil {
-		c.handleEviction(evicted)
+	if replaced != nil {
+		c.handleEviction(replaced)
 	}
+
+	c.notify(Event[K, V]{Type: EventSet, Key: key, Value: val})
+	c.handleEviction(evicted...)
 }
 
 // Add assigns a new value to an item at given key if it doesn't exist.
-func (c *LRUCache) Add(key string, val interface{}) bool {
+func (c *LRU[K, V]) Add(key K, val V) bool {
 	return c.AddTTL(key, val, 0)
 }
 
-func (c *LRUCache) AddTTL(key string, val interface{}, ttl time.Duration) bool {
+func (c *LRU[K, V]) AddTTL(key K, val V, ttl time.Duration) bool {
 	c.mu.Lock()
 
 	curVal, ok := c.valueMap[key]
 	if ok {
 		cItem := curVal.(*Item)
-		cItem.expiration = time.Now().Add(cItem.ttl).UnixNano()
-		c.sortMove(cItem.valuesListElement)
+		cItem.expiration = c.expiration(cItem.ttl)
+		c.sortMove(cItem)
+		c.access(key, cItem)
+		v, _ := cItem.object.(V)
 
 		c.mu.Unlock()
 
+		c.notify(Event[K, V]{Type: EventRefresh, Key: key, Value: v})
+
 		return false
 	}
 
-	c.set(key, val, ttl)
+	evicted := c.set(key, val, ttl)
 	c.mu.Unlock()
 
+	c.notify(Event[K, V]{Type: EventSet, Key: key, Value: val})
+	c.handleEviction(evicted...)
+
 	return true
 }
 
 // Delete removes an item at given key.
-func (c *LRUCache) Delete(key string) bool {
+func (c *LRU[K, V]) Delete(key K) bool {
 	c.mu.Lock()
 
 	curVal, ok := c.valueMap[key]
 	if ok {
-		evicted := c.delete(curVal.(*Item).valuesListElement)
+		evicted := c.delete(curVal.(*Item), EvictionReasonDeleted)
 
 		c.mu.Unlock()
 
@@ -138,13 +249,16 @@ func (c *LRUCache) Delete(key string) bool {
 }
 
 // Reduce iterates through values and calls func() with key, val and previous returned value.
-func (c *LRUCache) Reduce(f func(key string, val interface{}, total interface{}) interface{}) interface{} {
+func (c *LRU[K, V]) Reduce(f func(key K, val V, total interface{}) interface{}) interface{} {
+	return c.reduce(f, nil)
+}
+
+func (c *LRU[K, V]) reduce(f func(key K, val V, total interface{}) interface{}, total interface{}) interface{} {
 	c.mu.Lock()
-	var total interface{}
 
 	for key, val := range c.valueMap {
-		cItem := val.(*Item)
-		total = f(key, cItem.object, total)
+		v, _ := val.(*Item).object.(V)
+		total = f(key, v, total)
 	}
 
 	c.mu.Unlock()
@@ -152,8 +266,8 @@ func (c *LRUCache) Reduce(f func(key string, val interface{}, total interface{})
 	return total
 }
 
-// Contains returns true if item exists, false otherwise. Doesn't affect the order of recently used items.
-func (c *LRUCache) Contains(key string) bool {
+// Contains returns true if item exists (even if stale), false otherwise. Doesn't affect the order of recently used items.
+func (c *LRU[K, V]) Contains(key K) bool {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -166,3 +280,23 @@ func (c *LRUCache) Contains(key string) bool {
 
 	return time.Now().UnixNano() < cItem.expiration
 }
+
+// LRUCache describes an untyped struct for caching. It is a thin wrapper over LRU with string keys.
+type LRUCache struct {
+	LRU[string, interface{}]
+}
+
+// NewLRUCache creates and initializes a new cache object.
+// This one is based on LRU KV with TTL
+func NewLRUCache(autoRefresh bool, opts ...Option) *LRUCache {
+	cache := LRUCache{}
+	cache.init(autoRefresh, opts...)
+
+	return &cache
+}
+
+// Get returns an item at given key. It automatically extends the expiration if auto refresh is true. Returns the item or nil.
+func (c *LRUCache) Get(key string) interface{} {
+	val, _ := c.LRU.Get(key)
+	return val
+}
@@ -1,29 +1,66 @@
 package cache
 
 import (
+	"container/heap"
 	"time"
 )
 
-// LRUSetCache describes a struct for caching.
-type LRUSetCache struct {
-	Cache
+// LRUSet describes a typed struct for caching sets of values. Len returns number of values in whole cache,
+// use KeyLen for number of values in a set at given key.
+type LRUSet[K, V comparable] struct {
+	Base[K]
+
+	// keyOrder orders values of each key by expiration when MaxPerKey is set so that the oldest one is found
+	// without scanning all values of a key.
+	keyOrder map[K]*keyExpirationHeap
 }
 
-// NewLRUSetCache creates and initializes a new cache object.
+// NewLRUSet creates and initializes a new typed cache object.
 // This one is based on LRU K->List with TTL
-func NewLRUSetCache(opts ...Option) *LRUSetCache {
-	cache := LRUSetCache{}
-	cache.Cache.Init(cache.deleteHandler, opts...)
+func NewLRUSet[K, V comparable](opts ...Option) *LRUSet[K, V] {
+	cache := LRUSet[K, V]{}
+	cache.init(opts...)
 
 	return &cache
 }
 
+func (c *LRUSet[K, V]) init(opts ...Option) {
+	c.keyOrder = make(map[K]*keyExpirationHeap)
+	c.Base.Init(c.deleteHandler, opts...)
+}
+
+// OnValueEvicted sets an (optional) function that is called with the key and value when value is evicted from the cache.
+// Set to nil to disable.
+func (c *LRUSet[K, V]) OnValueEvicted(f func(K, V)) {
+	if f == nil {
+		c.Base.OnValueEvicted(nil)
+		return
+	}
+
+	c.Base.OnValueEvicted(func(key K, val interface{}) {
+		v, _ := val.(V)
+		f(key, v)
+	})
+}
+
+// AddEvictionHandler registers a function that is called with the key, value and reason whenever value is evicted
+// from the cache. Returns a function that unregisters the handler.
+func (c *LRUSet[K, V]) AddEvictionHandler(f func(K, V, EvictionReason)) (remove func()) {
+	return c.Base.AddEvictionHandler(func(key K, val interface{}, reason EvictionReason) {
+		v, _ := val.(V)
+		f(key, v, reason)
+	})
+}
+
 // Get returns all items at given key. Returns list of items or nil.
-func (c *LRUSetCache) Get(key string) []interface{} {
-	return c.get(key)
+func (c *LRUSet[K, V]) Get(key K) []V {
+	values := c.get(key)
+	c.recordLookup(len(values) > 0)
+
+	return values
 }
 
-func (c *LRUSetCache) get(key string) []interface{} {
+func (c *LRUSet[K, V]) get(key K) []V {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -32,13 +69,14 @@ func (c *LRUSetCache) get(key string) []interface{} {
 		return nil
 	}
 
-	var values []interface{}
+	var values []V
 
-	cItemMap := val.(map[interface{}]*Item)
+	cItemMap := val.(map[V]*Item)
 
-	for _, cItem := range cItemMap {
+	for v, cItem := range cItemMap {
 		if time.Now().UnixNano() < cItem.expiration {
-			values = append(values, cItem.object)
+			values = append(values, v)
+			c.access(key, cItem)
 		}
 	}
 
@@ -46,7 +84,7 @@ func (c *LRUSetCache) get(key string) []interface{} {
 }
 
 // Refresh extends the expiration of given key. Returns true on success.
-func (c *LRUSetCache) Refresh(key string, val interface{}) bool {
+func (c *LRUSet[K, V]) Refresh(key K, val V) bool {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -55,14 +93,16 @@ func (c *LRUSetCache) Refresh(key string, val interface{}) bool {
 		return false
 	}
 
-	cItem, ok := v.(map[interface{}]*Item)[val]
+	cItem, ok := v.(map[V]*Item)[val]
 	if !ok {
 		return false
 	}
 
-	if cItem.object == val && time.Now().UnixNano() < cItem.expiration {
+	if time.Now().UnixNano() < cItem.expiration {
 		cItem.expiration = time.Now().Add(cItem.ttl).UnixNano()
-		c.sortMove(cItem.valuesListElement)
+		c.sortMove(cItem)
+		c.fixOrder(key, cItem)
+		c.access(key, cItem)
 
 		return true
 	}
@@ -71,53 +111,117 @@ func (c *LRUSetCache) Refresh(key string, val interface{}) bool {
 }
 
 // Add assigns a new value to an item at given key if it doesn't exist.
-func (c *LRUSetCache) Add(key string, val interface{}) bool {
+func (c *LRUSet[K, V]) Add(key K, val V) bool {
 	return c.AddTTL(key, val, 0)
 }
 
-func (c *LRUSetCache) AddTTL(key string, val interface{}, ttl time.Duration) bool {
+func (c *LRUSet[K, V]) AddTTL(key K, val V, ttl time.Duration) bool {
+	return c.AddManyTTL(key, ttl, val) == 1
+}
+
+// AddMany assigns new values to an item at given key. Values that already exist are refreshed.
+// Returns number of values added.
+func (c *LRUSet[K, V]) AddMany(key K, vals ...V) int {
+	return c.AddManyTTL(key, 0, vals...)
+}
+
+// AddManyTTL assigns new values with given ttl to an item at given key. Values that already exist are refreshed.
+// Returns number of values added.
+func (c *LRUSet[K, V]) AddManyTTL(key K, ttl time.Duration, vals ...V) int {
+	var (
+		evicted []*keyValue[K]
+		added   int
+	)
+
 	if ttl == 0 {
 		ttl = c.cfg.TTL
 	}
 
 	c.mu.Lock()
 
-	curVal, ok := c.valueMap[key]
-	if ok {
-		if cItem, ok2 := curVal.(map[interface{}]*Item)[val]; ok2 {
-			cItem.expiration = time.Now().Add(ttl).UnixNano()
-			c.sortMove(cItem.valuesListElement)
-			c.mu.Unlock()
+	for _, val := range vals {
+		if curVal, ok := c.valueMap[key]; ok {
+			if cItem, ok2 := curVal.(map[V]*Item)[val]; ok2 {
+				cItem.expiration = time.Now().Add(ttl).UnixNano()
+				c.sortMove(cItem)
+				c.fixOrder(key, cItem)
+				c.access(key, cItem)
 
-			return false
+				continue
+			}
 		}
+
+		// Add item.
+		evicted = append(evicted, c.addItem(key, val, &Item{object: val, expiration: time.Now().Add(ttl).UnixNano(), ttl: ttl})...)
+		added++
 	}
 
-	// Add item.
-	cItem := &Item{object: val, expiration: time.Now().Add(ttl).UnixNano(), ttl: ttl}
-	cItem.valuesListElement = c.add(&valuesItem{key: key, item: cItem})
-	c.checkLength()
+	c.mu.Unlock()
+
+	c.handleEviction(evicted...)
+
+	return added
+}
 
-	if ok {
-		curVal.(map[interface{}]*Item)[val] = cItem
+func (c *LRUSet[K, V]) addItem(key K, val V, cItem *Item) (evicted []*keyValue[K]) {
+	c.add(&valuesItem[K]{key: key, item: cItem})
+
+	if curVal, ok := c.valueMap[key]; ok {
+		curVal.(map[V]*Item)[val] = cItem
 	} else {
-		c.valueMap[key] = map[interface{}]*Item{val: cItem}
+		c.valueMap[key] = map[V]*Item{val: cItem}
 	}
 
-	c.mu.Unlock()
+	if c.cfg.MaxPerKey > 0 {
+		h, ok := c.keyOrder[key]
+		if !ok {
+			h = &keyExpirationHeap{}
+			c.keyOrder[key] = h
+		}
+
+		heap.Push(h, cItem)
 
-	return true
+		for h.Len() > c.cfg.MaxPerKey {
+			if valueEvicted := c.delete(h.oldest(cItem), EvictionReasonCapacity); valueEvicted != nil {
+				evicted = append(evicted, valueEvicted)
+			}
+		}
+	}
+
+	// Policy may reject the new value itself so it has to be stored before capacity check.
+	return append(evicted, c.checkLength()...)
+}
+
+// fixOrder reorders value in expiration order of its key after its expiration has changed.
+func (c *LRUSet[K, V]) fixOrder(key K, cItem *Item) {
+	if h, ok := c.keyOrder[key]; ok && h.contains(cItem) {
+		heap.Fix(h, cItem.keyIndex)
+	}
+}
+
+// removeOrder removes value from expiration order of its key.
+func (c *LRUSet[K, V]) removeOrder(key K, cItem *Item) {
+	h, ok := c.keyOrder[key]
+	if !ok || !h.contains(cItem) {
+		return
+	}
+
+	heap.Remove(h, cItem.keyIndex)
+
+	if h.Len() == 0 {
+		delete(c.keyOrder, key)
+	}
 }
 
 // Delete removes an item at given key.
-func (c *LRUSetCache) Delete(key string, val interface{}) bool {
-	var evicted *keyValue
+func (c *LRUSet[K, V]) Delete(key K, val V) bool {
+	var evicted *keyValue[K]
 
 	c.mu.Lock()
 
 	if curVal, ok := c.valueMap[key]; ok {
-		if v, ok := curVal.(map[interface{}]*Item)[val]; ok {
-			evicted = c.delete(v.valuesListElement)
+		if v, ok := curVal.(map[V]*Item)[val]; ok {
+			evicted = c.delete(v, EvictionReasonDeleted)
 		}
 	}
 
@@ -132,18 +236,65 @@ func (c *LRUSetCache) Delete(key string, val interface{}) bool {
 	return false
 }
 
+// DeleteKey removes all items at given key. Eviction handlers are called for each removed value.
+// Returns number of values removed.
+func (c *LRUSet[K, V]) DeleteKey(key K) int {
+	var evicted []*keyValue[K]
+
+	c.mu.Lock()
+
+	if curVal, ok := c.valueMap[key]; ok {
+		m := curVal.(map[V]*Item)
+		items := make([]*Item, 0, len(m))
+
+		for _, cItem := range m {
+			items = append(items, cItem)
+		}
+
+		for _, cItem := range items {
+			if valueEvicted := c.delete(cItem, EvictionReasonDeleted); valueEvicted != nil {
+				evicted = append(evicted, valueEvicted)
+			}
+		}
+	}
+
+	c.mu.Unlock()
+
+	c.handleEviction(evicted...)
+
+	return len(evicted)
+}
+
+// KeyLen returns number of values at given key that haven't expired. It is not named Len as that one is promoted
+// from Base and returns number of values in whole cache.
+func (c *LRUSet[K, V]) KeyLen(key K) int {
+	var n int
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if curVal, ok := c.valueMap[key]; ok {
+		now := time.Now().UnixNano()
+
+		for _, cItem := range curVal.(map[V]*Item) {
+			if now < cItem.expiration {
+				n++
+			}
+		}
+	}
+
+	return n
+}
+
 // Reduce iterates through values and calls func() with key, val and previous returned value.
-func (c *LRUSetCache) Reduce(f func(key string, val interface{}, total interface{}) interface{}) interface{} {
+func (c *LRUSet[K, V]) Reduce(f func(key K, val V, total interface{}) interface{}) interface{} {
 	c.mu.Lock()
-	var (
-		total interface{}
-		item  *valuesItem
-	)
+	var total interface{}
 
-	for e := c.valuesList.Front(); e != nil; {
-		item = e.Value.(*valuesItem)
-		total = f(item.key, item.item.object, total)
-		e = e.Next()
+	for key, val := range c.valueMap {
+		for v := range val.(map[V]*Item) {
+			total = f(key, v, total)
+		}
 	}
 
 	c.mu.Unlock()
@@ -152,7 +303,7 @@ func (c *LRUSetCache) Reduce(f func(key string, val interface{}, total interface
 }
 
 // Contains returns true if item exists, false otherwise. Doesn't affect the order of recently used items.
-func (c *LRUSetCache) Contains(key string, val interface{}) bool {
+func (c *LRUSet[K, V]) Contains(key K, val V) bool {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -161,7 +312,7 @@ func (c *LRUSetCache) Contains(key string, val interface{}) bool {
 		return false
 	}
 
-	cItem, ok := curVal.(map[interface{}]*Item)[val]
+	cItem, ok := curVal.(map[V]*Item)[val]
 	if !ok {
 		return false
 	}
@@ -169,19 +320,37 @@ func (c *LRUSetCache) Contains(key string, val interface{}) bool {
 	return time.Now().UnixNano() < cItem.expiration
 }
 
-func (c *LRUSetCache) deleteHandler(item *valuesItem) *keyValue {
+func (c *LRUSet[K, V]) deleteHandler(item *valuesItem[K]) *keyValue[K] {
 	if curVal, ok := c.valueMap[item.key]; ok {
-		m := curVal.(map[interface{}]*Item)
-		if _, ok := m[item.item.object]; ok {
+		m := curVal.(map[V]*Item)
+		v, _ := item.item.object.(V)
+
+		if _, ok := m[v]; ok {
+			c.removeOrder(item.key, item.item)
+
 			if len(m) == 1 {
 				delete(c.valueMap, item.key)
 			} else {
-				delete(m, item.item.object)
+				delete(m, v)
 			}
 
-			return &keyValue{key: item.key, value: item.item.object}
+			return &keyValue[K]{key: item.key, value: item.item.object}
 		}
 	}
 
 	return nil
 }
+
+// LRUSetCache describes an untyped struct for caching. It is a thin wrapper over LRUSet with string keys.
+type LRUSetCache struct {
+	LRUSet[string, interface{}]
+}
+
+// NewLRUSetCache creates and initializes a new cache object.
+// This one is based on LRU K->List with TTL
+func NewLRUSetCache(opts ...Option) *LRUSetCache {
+	cache := LRUSetCache{}
+	cache.init(opts...)
+
+	return &cache
+}
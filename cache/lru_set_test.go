@@ -79,16 +79,16 @@ func TestLRUSetCache(t *testing.T) {
 				})
 				Convey("Get doesn't affect refreshes expiration time", func() {
 					exp := c.valueMap["key1"].(map[interface{}]*Item)["value1"].expiration
-					So(c.valuesList.Back().Value.(*valuesItem).key, ShouldNotEqual, "key1")
+					So(backKey(&c.Base), ShouldNotEqual, "key1")
 					c.Get("key1")
-					So(c.valuesList.Back().Value.(*valuesItem).key, ShouldNotEqual, "key1")
+					So(backKey(&c.Base), ShouldNotEqual, "key1")
 					So(exp, ShouldEqual, c.valueMap["key1"].(map[interface{}]*Item)["value1"].expiration)
 				})
 				Convey("Refresh refreshes expiration time and moves element to back of LRU", func() {
 					exp := c.valueMap["key1"].(map[interface{}]*Item)["value1"].expiration
-					So(c.valuesList.Back().Value.(*valuesItem).key, ShouldNotEqual, "key1")
+					So(backKey(&c.Base), ShouldNotEqual, "key1")
 					So(c.Refresh("key1", "value1"), ShouldBeTrue)
-					So(c.valuesList.Back().Value.(*valuesItem).key, ShouldEqual, "key1")
+					So(backKey(&c.Base), ShouldEqual, "key1")
 					So(exp, ShouldBeLessThan, c.valueMap["key1"].(map[interface{}]*Item)["value1"].expiration)
 				})
 				Convey("Refresh returns false if key or value was not found", func() {
@@ -97,22 +97,22 @@ func TestLRUSetCache(t *testing.T) {
 				})
 				Convey("Contains doesn't affect expiration time", func() {
 					exp := c.valueMap["key1"].(map[interface{}]*Item)["value1"].expiration
-					So(c.valuesList.Back().Value.(*valuesItem).key, ShouldNotEqual, "key1")
+					So(backKey(&c.Base), ShouldNotEqual, "key1")
 					So(c.Contains("key1", "value1"), ShouldBeTrue)
-					So(c.valuesList.Back().Value.(*valuesItem).key, ShouldNotEqual, "key1")
+					So(backKey(&c.Base), ShouldNotEqual, "key1")
 					So(exp, ShouldEqual, c.valueMap["key1"].(map[interface{}]*Item)["value1"].expiration)
 				})
 				Convey("Delete removes a key", func() {
 					mh.On("OnValueEvicted", "key1", "value1").Once()
 					So(c.Delete("key1", "value1"), ShouldBeTrue)
 					So(c.Delete("key1", "value1"), ShouldBeFalse)
-					So(c.deleteHandler(&valuesItem{key: "key3"}), ShouldBeNil)
+					So(c.deleteHandler(&valuesItem[string]{key: "key3"}), ShouldBeNil)
 					So(c.Len(), ShouldEqual, 1)
 					So(c.Get("key1"), ShouldBeNil)
 				})
 				Convey("deleteHandler should return nil for non existing key", func() {
 					So(c.Len(), ShouldEqual, 2)
-					So(c.deleteHandler(&valuesItem{key: "key3"}), ShouldBeNil)
+					So(c.deleteHandler(&valuesItem[string]{key: "key3"}), ShouldBeNil)
 					So(c.Len(), ShouldEqual, 2)
 				})
 				Convey("Reduce calls function on each key, value pair", func() {
@@ -163,3 +163,117 @@ func TestLRUSetCache(t *testing.T) {
 		c.StopJanitor()
 	})
 }
+
+func TestLRUSet(t *testing.T) {
+	Convey("Given new typed LRU set", t, func() {
+		c := NewLRUSet[int, string]()
+
+		Convey("Add adds typed values that can be retrieved by Get", func() {
+			So(c.Add(1, "a"), ShouldBeTrue)
+			So(c.Add(1, "b"), ShouldBeTrue)
+			So(c.Get(1), ShouldHaveLength, 2)
+			So(c.Contains(1, "a"), ShouldBeTrue)
+
+			Convey("OnValueEvicted is called with typed values", func() {
+				var evicted []string
+				c.OnValueEvicted(func(key int, val string) {
+					evicted = append(evicted, val)
+				})
+				So(c.Delete(1, "a"), ShouldBeTrue)
+				So(evicted, ShouldResemble, []string{"a"})
+				So(c.Get(1), ShouldResemble, []string{"b"})
+			})
+		})
+
+		c.StopJanitor()
+	})
+}
+
+func TestLRUSetBulk(t *testing.T) {
+	Convey("Given new LRU set cache with large max per key", t, func() {
+		c := NewLRUSet[int, int](WithMaxPerKey(1000), WithCapacity(0))
+
+		Convey("AddMany keeps the most recent values", func() {
+			vals := make([]int, 100000)
+			for i := range vals {
+				vals[i] = i
+			}
+
+			So(c.AddMany(1, vals...), ShouldEqual, len(vals))
+			So(c.KeyLen(1), ShouldEqual, 1000)
+			So(c.Contains(1, 98999), ShouldBeFalse)
+			So(c.Contains(1, 99000), ShouldBeTrue)
+			So(c.keyOrder[1].Len(), ShouldEqual, 1000)
+		})
+
+		c.StopJanitor()
+	})
+
+	Convey("Given new LRU set cache with max per key", t, func() {
+		c := NewLRUSetCache(WithMaxPerKey(3))
+		mh := new(MockHandler)
+		c.OnValueEvicted(mh.OnValueEvicted)
+
+		Convey("AddMany adds all new values and refreshes existing ones", func() {
+			So(c.AddMany("key1", "a", "b"), ShouldEqual, 2)
+			exp := c.valueMap["key1"].(map[interface{}]*Item)["a"].expiration
+
+			So(c.AddMany("key1", "a", "c"), ShouldEqual, 1)
+			So(c.KeyLen("key1"), ShouldEqual, 3)
+			So(c.KeyLen("key2"), ShouldEqual, 0)
+			So(c.valueMap["key1"].(map[interface{}]*Item)["a"].expiration, ShouldBeGreaterThan, exp)
+		})
+		Convey("values over max per key evict key's oldest values", func() {
+			c.AddMany("key1", "a", "b", "c")
+			c.Add("key2", "x")
+			c.Refresh("key1", "a")
+			mh.On("OnValueEvicted", "key1", "b").Once()
+			mh.On("OnValueEvicted", "key1", "c").Once()
+
+			So(c.AddMany("key1", "d", "e"), ShouldEqual, 2)
+			So(c.KeyLen("key1"), ShouldEqual, 3)
+			So(c.Contains("key1", "a"), ShouldBeTrue)
+			So(c.Contains("key1", "e"), ShouldBeTrue)
+			So(c.KeyLen("key2"), ShouldEqual, 1)
+			So(c.Stats().Evictions[EvictionReasonCapacity], ShouldEqual, 2)
+			mh.AssertExpectations(t)
+		})
+		Convey("values closest to expiring are evicted first regardless of insertion order", func() {
+			c.AddTTL("key1", "a", time.Hour)
+			c.AddTTL("key1", "b", time.Minute)
+			c.AddTTL("key1", "c", 2*time.Hour)
+			mh.On("OnValueEvicted", "key1", "b").Once()
+
+			So(c.AddTTL("key1", "d", time.Second), ShouldBeTrue)
+			So(c.Contains("key1", "d"), ShouldBeTrue)
+			mh.AssertExpectations(t)
+		})
+		Convey("order of key is dropped with its last value", func() {
+			c.AddMany("key1", "a", "b")
+			So(c.keyOrder, ShouldHaveLength, 1)
+			mh.On("OnValueEvicted", "key1", mock.Anything).Twice()
+
+			c.DeleteKey("key1")
+			So(c.keyOrder, ShouldBeEmpty)
+		})
+		Convey("KeyLen doesn't count expired values", func() {
+			c.AddMany("key1", "a", "b")
+			c.valueMap["key1"].(map[interface{}]*Item)["a"].expiration = 1
+			So(c.KeyLen("key1"), ShouldEqual, 1)
+		})
+		Convey("DeleteKey removes whole set and calls handler for each value", func() {
+			c.AddMany("key1", "a", "b")
+			c.Add("key2", "x")
+			mh.On("OnValueEvicted", "key1", "a").Once()
+			mh.On("OnValueEvicted", "key1", "b").Once()
+
+			So(c.DeleteKey("key1"), ShouldEqual, 2)
+			So(c.DeleteKey("key1"), ShouldEqual, 0)
+			So(c.Get("key1"), ShouldBeNil)
+			So(c.Len(), ShouldEqual, 1)
+			mh.AssertExpectations(t)
+		})
+
+		c.StopJanitor()
+	})
+}
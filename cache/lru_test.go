@@ -13,7 +13,7 @@ func TestLRUCache(t *testing.T) {
 		c := NewLRUCache(true, WithCapacity(3), WithCleanupInterval(1*time.Millisecond))
 
 		So(c.janitor, ShouldNotBeNil)
-		So(c.deleteHandler, ShouldEqual, c.defaultDeleteHandler)
+		So(c.deleteHandler, ShouldNotBeNil)
 		mh := new(MockHandler)
 
 		Convey("Get/Contains of non existent key returns nil/false", func() {
@@ -73,41 +73,41 @@ func TestLRUCache(t *testing.T) {
 				})
 				Convey("Add on same key returns false and refreshes expiration", func() {
 					exp := c.valueMap["key1"].(*Item).expiration
-					So(c.valuesList.Back().Value.(*valuesItem).key, ShouldNotEqual, "key1")
+					So(backKey(&c.Base), ShouldNotEqual, "key1")
 					So(c.Add("key1", "value3"), ShouldBeFalse)
 					So(c.Len(), ShouldEqual, 2)
-					So(c.valuesList.Back().Value.(*valuesItem).key, ShouldEqual, "key1")
+					So(backKey(&c.Base), ShouldEqual, "key1")
 					So(exp, ShouldBeLessThan, c.valueMap["key1"].(*Item).expiration)
 					So(c.Get("key1"), ShouldEqual, "value1")
 				})
 				Convey("Get refreshes expiration time and moves element to back of LRU", func() {
 					exp := c.valueMap["key1"].(*Item).expiration
-					So(c.valuesList.Back().Value.(*valuesItem).key, ShouldNotEqual, "key1")
+					So(backKey(&c.Base), ShouldNotEqual, "key1")
 					c.Get("key1")
-					So(c.valuesList.Back().Value.(*valuesItem).key, ShouldEqual, "key1")
+					So(backKey(&c.Base), ShouldEqual, "key1")
 					So(exp, ShouldBeLessThan, c.valueMap["key1"].(*Item).expiration)
 				})
 				Convey("Get with autorefresh disabled - doesn't refresh expiration time", func() {
 					c.autoRefresh = false
 					exp := c.valueMap["key1"].(*Item).expiration
-					So(c.valuesList.Back().Value.(*valuesItem).key, ShouldNotEqual, "key1")
+					So(backKey(&c.Base), ShouldNotEqual, "key1")
 					c.Get("key1")
-					So(c.valuesList.Back().Value.(*valuesItem).key, ShouldNotEqual, "key1")
+					So(backKey(&c.Base), ShouldNotEqual, "key1")
 					So(exp, ShouldEqual, c.valueMap["key1"].(*Item).expiration)
 				})
 				Convey("Refresh refreshes expiration time and moves element to back of LRU", func() {
 					c.autoRefresh = false
 					exp := c.valueMap["key1"].(*Item).expiration
-					So(c.valuesList.Back().Value.(*valuesItem).key, ShouldNotEqual, "key1")
+					So(backKey(&c.Base), ShouldNotEqual, "key1")
 					c.Refresh("key1")
-					So(c.valuesList.Back().Value.(*valuesItem).key, ShouldEqual, "key1")
+					So(backKey(&c.Base), ShouldEqual, "key1")
 					So(exp, ShouldBeLessThan, c.valueMap["key1"].(*Item).expiration)
 				})
 				Convey("Contains doesn't affect expiration time", func() {
 					exp := c.valueMap["key1"].(*Item).expiration
-					So(c.valuesList.Back().Value.(*valuesItem).key, ShouldNotEqual, "key1")
+					So(backKey(&c.Base), ShouldNotEqual, "key1")
 					c.Contains("key1")
-					So(c.valuesList.Back().Value.(*valuesItem).key, ShouldNotEqual, "key1")
+					So(backKey(&c.Base), ShouldNotEqual, "key1")
 					So(exp, ShouldEqual, c.valueMap["key1"].(*Item).expiration)
 				})
 				Convey("Delete removes a key", func() {
@@ -165,3 +165,100 @@ func TestLRUCache(t *testing.T) {
 		c.StopJanitor()
 	})
 }
+
+func TestLRU(t *testing.T) {
+	Convey("Given new typed LRU cache", t, func() {
+		type value struct{ n int }
+
+		c := NewLRU[int, *value](false, WithCapacity(2))
+
+		Convey("Get of non existent key returns zero value and false", func() {
+			v, ok := c.Get(1)
+			So(v, ShouldBeNil)
+			So(ok, ShouldBeFalse)
+		})
+		Convey("Set adds a new typed item that can be retrieved by Get", func() {
+			val := &value{n: 1}
+			c.Set(1, val)
+			v, ok := c.Get(1)
+			So(ok, ShouldBeTrue)
+			So(v, ShouldEqual, val)
+
+			Convey("Reduce passes typed values", func() {
+				total := c.Reduce(func(key int, val *value, total interface{}) interface{} {
+					if total == nil {
+						return val.n
+					}
+					return total.(int) + val.n
+				})
+				So(total, ShouldEqual, 1)
+			})
+			Convey("OnValueEvicted is called with typed values", func() {
+				var evicted []*value
+				c.OnValueEvicted(func(key int, val *value) {
+					evicted = append(evicted, val)
+				})
+				c.Set(2, &value{n: 2})
+				So(c.Delete(1), ShouldBeTrue)
+				So(evicted, ShouldResemble, []*value{val})
+
+				c.OnValueEvicted(nil)
+				So(c.Delete(2), ShouldBeTrue)
+				So(evicted, ShouldHaveLength, 1)
+			})
+		})
+
+		c.StopJanitor()
+	})
+}
+
+func TestLRUCacheWeight(t *testing.T) {
+	Convey("Given new LRU cache with weigher and max weight", t, func() {
+		c := NewLRUCache(false, WithMaxWeight(10), WithWeigher(func(key string, val interface{}) int64 {
+			return int64(len(val.(string)))
+		}))
+
+		Convey("Weight tracks total weight of values", func() {
+			c.Set("key1", "1234")
+			c.Set("key2", "12")
+			So(c.Weight(), ShouldEqual, 6)
+			So(c.Stats().Weight, ShouldEqual, 6)
+
+			c.Set("key1", "1")
+			So(c.Weight(), ShouldEqual, 3)
+			c.Delete("key2")
+			So(c.Weight(), ShouldEqual, 1)
+		})
+		Convey("Set evicts values closest to expiring when over max weight", func() {
+			c.Set("key1", "1234")
+			c.Set("key2", "1234")
+			c.Set("key3", "1234")
+			So(c.Len(), ShouldEqual, 2)
+			So(c.Weight(), ShouldEqual, 8)
+			So(c.Contains("key1"), ShouldBeFalse)
+		})
+		Convey("value heavier than max weight is not kept", func() {
+			c.Set("key1", "12345678901")
+			So(c.Len(), ShouldEqual, 0)
+			So(c.Weight(), ShouldEqual, 0)
+		})
+
+		c.StopJanitor()
+	})
+	Convey("Given new LRU cache with max weight only", t, func() {
+		c := NewLRU[int, int](false, WithMaxWeight(2))
+
+		Convey("each value weighs 1", func() {
+			c.Set(1, 1)
+			c.Set(2, 2)
+			c.Set(3, 3)
+			So(c.Len(), ShouldEqual, 2)
+			So(c.Weight(), ShouldEqual, 2)
+		})
+
+		c.StopJanitor()
+	})
+	Convey("Typed cache with weigher of different key type panics", t, func() {
+		So(func() { NewLRU[int, int](false, WithWeigher(func(string, interface{}) int64 { return 1 })) }, ShouldPanic)
+	})
+}
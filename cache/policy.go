@@ -0,0 +1,384 @@
+package cache
+
+import (
+	"container/heap"
+	"container/list"
+	"encoding/binary"
+	"fmt"
+	"hash/maphash"
+)
+
+// Policy decides which value is evicted when cache is over its capacity or max weight.
+// Policy methods are called with cache mutex held so they don't need to be thread-safe.
+// Expired values are always removed in expiration order regardless of policy.
+type Policy[K comparable] interface {
+	// OnAdd is called when item is added to cache.
+	OnAdd(key K, item *Item)
+	// OnAccess is called when item is read or refreshed.
+	OnAccess(key K, item *Item)
+	// OnRemove is called when item is removed from cache for any reason.
+	OnRemove(item *Item)
+	// Victim returns item that should be evicted next or nil to evict item closest to expiring.
+	// It is only called when cache is over its capacity or max weight and returned item is removed right after,
+	// so policy may update its state in it, e.g. admit an item it decided to keep instead.
+	Victim() *Item
+}
+
+// WithPolicy sets eviction policy used when cache is over its capacity or max weight.
+// newPolicy is called with cache config once for every cache (or shard) created.
+// By default values closest to expiring are evicted first. Cache panics on init if K doesn't match its key type.
+func WithPolicy[K comparable](newPolicy func(cfg Config) Policy[K]) Option {
+	return func(config *Config) {
+		config.policy = newPolicy
+	}
+}
+
+// lfuPolicy evicts least frequently used item, ties are broken by least recently used one.
+type lfuPolicy[K comparable] struct {
+	entries lfuHeap
+	items   map[*Item]*lfuEntry
+	seq     uint64
+}
+
+type lfuEntry struct {
+	item  *Item
+	freq  uint64
+	seq   uint64
+	index int
+}
+
+// NewLFUPolicy creates least frequently used eviction policy. Access counts are kept only while value is in cache.
+func NewLFUPolicy[K comparable](cfg Config) Policy[K] {
+	return &lfuPolicy[K]{items: make(map[*Item]*lfuEntry)}
+}
+
+func (p *lfuPolicy[K]) nextSeq() uint64 {
+	p.seq++
+	return p.seq
+}
+
+func (p *lfuPolicy[K]) OnAdd(key K, item *Item) {
+	e := &lfuEntry{item: item, freq: 1, seq: p.nextSeq()}
+	p.items[item] = e
+	heap.Push(&p.entries, e)
+}
+
+func (p *lfuPolicy[K]) OnAccess(key K, item *Item) {
+	e, ok := p.items[item]
+	if !ok {
+		return
+	}
+
+	e.freq++
+	e.seq = p.nextSeq()
+	heap.Fix(&p.entries, e.index)
+}
+
+func (p *lfuPolicy[K]) OnRemove(item *Item) {
+	e, ok := p.items[item]
+	if !ok {
+		return
+	}
+
+	delete(p.items, item)
+	heap.Remove(&p.entries, e.index)
+}
+
+func (p *lfuPolicy[K]) Victim() *Item {
+	if len(p.entries) == 0 {
+		return nil
+	}
+
+	return p.entries[0].item
+}
+
+// lfuHeap implements heap.Interface.
+type lfuHeap []*lfuEntry
+
+func (h lfuHeap) Len() int {
+	return len(h)
+}
+
+func (h lfuHeap) Less(i, j int) bool {
+	if h[i].freq == h[j].freq {
+		return h[i].seq < h[j].seq
+	}
+
+	return h[i].freq < h[j].freq
+}
+
+func (h lfuHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *lfuHeap) Push(x interface{}) {
+	e := x.(*lfuEntry)
+	e.index = len(*h)
+	*h = append(*h, e)
+}
+
+func (h *lfuHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	e := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+
+	return e
+}
+
+type tinyLFUSegment int
+
+const (
+	segmentWindow tinyLFUSegment = iota
+	segmentProbation
+	segmentProtected
+)
+
+const (
+	tinyLFUWindowPercent    = 1
+	tinyLFUProtectedPercent = 80
+	// tinyLFUDefaultCapacity is a number of entries policy is sized for when cache has no Capacity set.
+	tinyLFUDefaultCapacity = 100
+)
+
+// tinyLFUPolicy is a W-TinyLFU eviction policy. New items enter a small LRU window, items leaving the window
+// are admitted to main segmented LRU only if they are estimated to be used more frequently than main's victim.
+// It keeps frequent items in cache when it is swept with values read only once.
+type tinyLFUPolicy[K comparable] struct {
+	hash   func(K) uint64
+	sketch *countMinSketch
+
+	windowCap    int
+	mainCap      int
+	protectedCap int
+
+	// Front of each list is the most recently used item.
+	segments [3]*list.List
+	items    map[*Item]*list.Element
+}
+
+type tinyLFUEntry struct {
+	item    *Item
+	hash    uint64
+	segment tinyLFUSegment
+}
+
+// NewTinyLFUPolicy creates W-TinyLFU eviction policy sized for cache Capacity.
+// Frequency of keys is tracked approximately and survives eviction so returning keys are recognized.
+// MaxWeight is not taken into account as it doesn't tell the number of entries, so cache limited only by MaxWeight
+// gets window and frequency sketch sized for 100 entries. Set Capacity as well to size them for larger caches.
+func NewTinyLFUPolicy[K comparable](cfg Config) Policy[K] {
+	capacity := cfg.Capacity
+	if capacity <= 0 {
+		capacity = tinyLFUDefaultCapacity
+	}
+
+	windowCap := capacity * tinyLFUWindowPercent / 100
+	if windowCap < 1 {
+		windowCap = 1
+	}
+
+	p := &tinyLFUPolicy[K]{
+		hash:         newKeyHasher[K](),
+		sketch:       newCountMinSketch(capacity),
+		windowCap:    windowCap,
+		mainCap:      capacity - windowCap,
+		protectedCap: (capacity - windowCap) * tinyLFUProtectedPercent / 100,
+		items:        make(map[*Item]*list.Element),
+	}
+
+	for i := range p.segments {
+		p.segments[i] = list.New()
+	}
+
+	return p
+}
+
+func (p *tinyLFUPolicy[K]) OnAdd(key K, item *Item) {
+	h := p.hash(key)
+	p.sketch.Increment(h)
+	p.items[item] = p.segments[segmentWindow].PushFront(&tinyLFUEntry{item: item, hash: h, segment: segmentWindow})
+
+	// Main is admitted to freely until it is full.
+	for p.segments[segmentWindow].Len() > p.windowCap && p.mainLen() < p.mainCap {
+		p.move(p.segments[segmentWindow].Back(), segmentProbation)
+	}
+}
+
+func (p *tinyLFUPolicy[K]) mainLen() int {
+	return p.segments[segmentProbation].Len() + p.segments[segmentProtected].Len()
+}
+
+func (p *tinyLFUPolicy[K]) OnAccess(key K, item *Item) {
+	e, ok := p.items[item]
+	if !ok {
+		return
+	}
+
+	entry := e.Value.(*tinyLFUEntry)
+	p.sketch.Increment(entry.hash)
+
+	switch entry.segment {
+	case segmentWindow, segmentProtected:
+		p.segments[entry.segment].MoveToFront(e)
+	case segmentProbation:
+		// Promote to protected segment and demote its least recently used items if it grew too big.
+		p.move(e, segmentProtected)
+
+		for p.segments[segmentProtected].Len() > p.protectedCap {
+			p.move(p.segments[segmentProtected].Back(), segmentProbation)
+		}
+	}
+}
+
+func (p *tinyLFUPolicy[K]) OnRemove(item *Item) {
+	e, ok := p.items[item]
+	if !ok {
+		return
+	}
+
+	delete(p.items, item)
+	p.segments[e.Value.(*tinyLFUEntry).segment].Remove(e)
+}
+
+// Victim returns main's victim or an item leaving the window. If the window item wins admission,
+// it is moved to probation segment and main's victim is returned instead.
+func (p *tinyLFUPolicy[K]) Victim() *Item {
+	victim := p.segments[segmentProbation].Back()
+	if victim == nil {
+		victim = p.segments[segmentProtected].Back()
+	}
+
+	// Item leaving the window is admitted to main only if it is used more frequently than main's victim.
+	if candidate := p.segments[segmentWindow].Back(); p.segments[segmentWindow].Len() > p.windowCap || victim == nil {
+		if victim == nil || p.sketch.Estimate(p.hashOf(candidate)) <= p.sketch.Estimate(p.hashOf(victim)) {
+			victim = candidate
+		} else {
+			p.move(candidate, segmentProbation)
+		}
+	}
+
+	if victim == nil {
+		return nil
+	}
+
+	return victim.Value.(*tinyLFUEntry).item
+}
+
+func (p *tinyLFUPolicy[K]) hashOf(e *list.Element) uint64 {
+	return e.Value.(*tinyLFUEntry).hash
+}
+
+func (p *tinyLFUPolicy[K]) move(e *list.Element, segment tinyLFUSegment) *list.Element {
+	entry := e.Value.(*tinyLFUEntry)
+	p.segments[entry.segment].Remove(e)
+	entry.segment = segment
+	e = p.segments[segment].PushFront(entry)
+	p.items[entry.item] = e
+
+	return e
+}
+
+const (
+	sketchDepth      = 4
+	sketchMaxCount   = 15
+	sketchSampleSize = 10
+	// Number of counters in each row per cache entry.
+	sketchWidthFactor = 4
+)
+
+var sketchSeeds = [sketchDepth]uint64{0xc3a5c85c97cb3127, 0xb492b66fbe98f273, 0x9ae16a3b2f90404f, 0xcbf29ce484222325}
+
+// countMinSketch estimates key frequencies with small 4-bit counters.
+// All counters are halved after sample size increments so that old popularity fades.
+type countMinSketch struct {
+	rows      [sketchDepth][]uint8
+	mask      uint64
+	additions int
+	resetAt   int
+}
+
+func newCountMinSketch(capacity int) *countMinSketch {
+	width := 16
+	for width < sketchWidthFactor*capacity {
+		width *= 2
+	}
+
+	s := &countMinSketch{mask: uint64(width - 1), resetAt: sketchSampleSize * capacity}
+	for i := range s.rows {
+		s.rows[i] = make([]uint8, width)
+	}
+
+	return s
+}
+
+func (s *countMinSketch) index(h uint64, row int) uint64 {
+	// Remix key hash with a different odd multiplier for each row so that rows collide independently.
+	h *= sketchSeeds[row]
+	h ^= h >> 32
+
+	return h & s.mask
+}
+
+func (s *countMinSketch) Increment(h uint64) {
+	for i := range s.rows {
+		if idx := s.index(h, i); s.rows[i][idx] < sketchMaxCount {
+			s.rows[i][idx]++
+		}
+	}
+
+	s.additions++
+	if s.additions >= s.resetAt {
+		s.reset()
+	}
+}
+
+func (s *countMinSketch) Estimate(h uint64) uint8 {
+	min := uint8(sketchMaxCount)
+
+	for i := range s.rows {
+		if v := s.rows[i][s.index(h, i)]; v < min {
+			min = v
+		}
+	}
+
+	return min
+}
+
+func (s *countMinSketch) reset() {
+	for i := range s.rows {
+		for j := range s.rows[i] {
+			s.rows[i][j] /= 2
+		}
+	}
+
+	s.additions /= 2
+}
+
+// newKeyHasher returns a hash function for keys of type K. Strings and integers are hashed directly,
+// other keys are hashed through their fmt representation.
+func newKeyHasher[K comparable]() func(K) uint64 {
+	seed := maphash.MakeSeed()
+
+	return func(key K) uint64 {
+		var buf [8]byte
+
+		switch k := interface{}(key).(type) {
+		case string:
+			return maphash.String(seed, k)
+		case int:
+			binary.LittleEndian.PutUint64(buf[:], uint64(k))
+		case int64:
+			binary.LittleEndian.PutUint64(buf[:], uint64(k))
+		case uint64:
+			binary.LittleEndian.PutUint64(buf[:], k)
+		default:
+			return maphash.String(seed, fmt.Sprint(k))
+		}
+
+		return maphash.Bytes(seed, buf[:])
+	}
+}
@@ -0,0 +1,175 @@
+package cache
+
+import (
+	"strconv"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestLFUPolicy(t *testing.T) {
+	Convey("Given LRU cache with LFU policy", t, func() {
+		c := NewLRUCache(false, WithCapacity(3), WithPolicy(NewLFUPolicy[string]))
+		mh := new(MockHandler)
+		c.OnValueEvicted(mh.OnValueEvicted)
+
+		c.Set("key1", "value1")
+		c.Set("key2", "value2")
+		c.Set("key3", "value3")
+
+		Convey("least frequently used value is evicted", func() {
+			c.Get("key1")
+			c.Get("key1")
+			c.Get("key3")
+			mh.On("OnValueEvicted", "key2", "value2").Once()
+
+			c.Set("key4", "value4")
+			So(c.Contains("key2"), ShouldBeFalse)
+			So(c.Len(), ShouldEqual, 3)
+			mh.AssertExpectations(t)
+		})
+		Convey("least recently used value is evicted between equally used ones", func() {
+			c.Get("key1")
+			c.Get("key2")
+			mh.On("OnValueEvicted", "key3", "value3").Once()
+
+			c.Set("key4", "value4")
+			So(c.Contains("key3"), ShouldBeFalse)
+			mh.AssertExpectations(t)
+		})
+		Convey("replaced value starts with fresh count", func() {
+			c.Get("key1")
+			c.Get("key2")
+			c.Get("key3")
+			mh.On("OnValueEvicted", "key1", "value1").Twice()
+
+			c.Set("key1", "value1")
+			c.Set("key4", "value4")
+			So(c.Contains("key1"), ShouldBeFalse)
+			mh.AssertExpectations(t)
+		})
+		Convey("deleted value is forgotten by policy", func() {
+			mh.On("OnValueEvicted", "key1", "value1").Once()
+			c.Delete("key1")
+			So(c.policy.(*lfuPolicy[string]).items, ShouldHaveLength, 2)
+			So(c.policy.(*lfuPolicy[string]).entries, ShouldHaveLength, 2)
+		})
+
+		c.StopJanitor()
+	})
+
+	Convey("Given LRU set cache with LFU policy", t, func() {
+		c := NewLRUSetCache(WithCapacity(2), WithPolicy(NewLFUPolicy[string]))
+
+		Convey("rejected new value is not kept in set", func() {
+			c.Add("key1", "a")
+			c.Add("key1", "b")
+			c.Get("key1")
+			c.Add("key2", "c")
+
+			So(c.Len(), ShouldEqual, 2)
+			So(c.Contains("key2", "c"), ShouldBeFalse)
+			So(c.valueMap, ShouldNotContainKey, "key2")
+			So(c.Get("key1"), ShouldHaveLength, 2)
+		})
+
+		c.StopJanitor()
+	})
+
+	Convey("Policy with mismatched key type panics", t, func() {
+		So(func() { NewLRUCache(false, WithPolicy(NewLFUPolicy[int])) }, ShouldPanic)
+	})
+}
+
+func TestTinyLFUPolicy(t *testing.T) {
+	const size = 100
+
+	hotKeysLeft := func(c *LRUCache) int {
+		var n int
+
+		for i := 0; i < size/2; i++ {
+			if c.Contains("hot" + strconv.Itoa(i)) {
+				n++
+			}
+		}
+
+		return n
+	}
+	scan := func(c *LRUCache) {
+		for i := 0; i < size/2; i++ {
+			for j := 0; j < 10; j++ {
+				c.Get("hot" + strconv.Itoa(i))
+			}
+		}
+
+		for i := 0; i < 10*size; i++ {
+			c.Set("scan"+strconv.Itoa(i), i)
+		}
+	}
+
+	Convey("Given LRU cache with W-TinyLFU policy filled with frequently used values", t, func() {
+		c := NewLRUCache(false, WithCapacity(size), WithPolicy(NewTinyLFUPolicy[string]))
+
+		for i := 0; i < size/2; i++ {
+			c.Set("hot"+strconv.Itoa(i), i)
+		}
+
+		Convey("frequently used values survive a scan of new keys", func() {
+			scan(c)
+			So(c.Len(), ShouldEqual, size)
+			// Frequency estimates are approximate so a hot key may occasionally lose to a colliding one.
+			So(hotKeysLeft(c), ShouldBeGreaterThanOrEqualTo, size/2-2)
+		})
+		Convey("cache doesn't grow over capacity and policy tracks all items", func() {
+			scan(c)
+			p := c.policy.(*tinyLFUPolicy[string])
+
+			So(p.items, ShouldHaveLength, size)
+			So(p.segments[segmentWindow].Len()+p.segments[segmentProbation].Len()+p.segments[segmentProtected].Len(),
+				ShouldEqual, size)
+			So(p.segments[segmentProtected].Len(), ShouldBeLessThanOrEqualTo, p.protectedCap)
+		})
+
+		c.StopJanitor()
+	})
+
+	Convey("Given LRU cache with default policy filled with frequently used values", t, func() {
+		c := NewLRUCache(false, WithCapacity(size))
+
+		for i := 0; i < size/2; i++ {
+			c.Set("hot"+strconv.Itoa(i), i)
+		}
+
+		Convey("scan of new keys evicts them", func() {
+			scan(c)
+			So(hotKeysLeft(c), ShouldEqual, 0)
+		})
+
+		c.StopJanitor()
+	})
+}
+
+func TestCountMinSketch(t *testing.T) {
+	Convey("Given count-min sketch", t, func() {
+		s := newCountMinSketch(16)
+
+		Convey("Estimate is capped at max count", func() {
+			for i := 0; i < 2*sketchMaxCount; i++ {
+				s.Increment(1)
+			}
+
+			So(s.Estimate(1), ShouldEqual, sketchMaxCount)
+			So(s.Estimate(2), ShouldEqual, 0)
+		})
+		Convey("counters are halved after sample size", func() {
+			s.resetAt = 8
+
+			for i := 0; i < 8; i++ {
+				s.Increment(1)
+			}
+
+			So(s.Estimate(1), ShouldEqual, 4)
+			So(s.additions, ShouldEqual, 4)
+		})
+	})
+}
@@ -0,0 +1,229 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/Syncano/pkg-go/v2/util"
+)
+
+// ShardedLRU describes a typed struct for caching that spreads keys across independent LRU shards.
+// Each shard has its own lock so concurrent access to different keys doesn't contend.
+// Capacity and max weight are split evenly between shards, so they are enforced approximately.
+type ShardedLRU[K comparable, V any] struct {
+	shards []*LRU[K, V]
+	hash   func(K) uint32
+	cfg    Config
+}
+
+// NewShardedLRU creates and initializes a new sharded typed cache object.
+// Hash is used to pick a shard for given key.
+func NewShardedLRU[K comparable, V any](shards int, hash func(K) uint32, autoRefresh bool, opts ...Option) *ShardedLRU[K, V] {
+	cache := ShardedLRU[K, V]{}
+	cache.init(shards, hash, autoRefresh, opts...)
+
+	return &cache
+}
+
+func (c *ShardedLRU[K, V]) init(shards int, hash func(K) uint32, autoRefresh bool, opts ...Option) {
+	if shards <= 0 {
+		shards = 1
+	}
+
+	cfg := DefaultConfig
+
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	c.cfg = cfg
+	c.hash = hash
+	c.shards = make([]*LRU[K, V], shards)
+
+	shardOpts := append(opts[:len(opts):len(opts)],
+		WithCapacity((cfg.Capacity+shards-1)/shards),
+		WithMaxWeight((cfg.MaxWeight+int64(shards)-1)/int64(shards)),
+	)
+
+	for i := range c.shards {
+		c.shards[i] = NewLRU[K, V](autoRefresh, shardOpts...)
+	}
+}
+
+// Config returns a copy of config struct.
+func (c *ShardedLRU[K, V]) Config() Config {
+	return c.cfg
+}
+
+// Shard returns shard responsible for given key.
+func (c *ShardedLRU[K, V]) Shard(key K) *LRU[K, V] {
+	return c.shards[c.hash(key)%uint32(len(c.shards))]
+}
+
+// StopJanitor is meant to be called when cache is no longer needed to avoid leaking goroutines.
+func (c *ShardedLRU[K, V]) StopJanitor() {
+	for _, s := range c.shards {
+		s.StopJanitor()
+	}
+}
+
+// Len returns total cache length.
+func (c *ShardedLRU[K, V]) Len() int {
+	var l int
+
+	for _, s := range c.shards {
+		l += s.Len()
+	}
+
+	return l
+}
+
+// Weight returns total weight of values in all shards.
+func (c *ShardedLRU[K, V]) Weight() int64 {
+	var w int64
+
+	for _, s := range c.shards {
+		w += s.Weight()
+	}
+
+	return w
+}
+
+// Stats returns a snapshot of cache statistics aggregated over all shards.
+func (c *ShardedLRU[K, V]) Stats() *Stats {
+	total := &Stats{Evictions: make(map[EvictionReason]uint64, evictionReasonCount)}
+
+	for _, s := range c.shards {
+		st := s.Stats()
+		total.Hits += st.Hits
+		total.Misses += st.Misses
+		total.Len += st.Len
+		total.Weight += st.Weight
+
+		for r, n := range st.Evictions {
+			total.Evictions[r] += n
+		}
+	}
+
+	return total
+}
+
+// OnValueEvicted sets an (optional) function that is called with the key and value when value is evicted from the cache.
+// Set to nil to disable.
+func (c *ShardedLRU[K, V]) OnValueEvicted(f func(K, V)) {
+	for _, s := range c.shards {
+		s.OnValueEvicted(f)
+	}
+}
+
+// AddEvictionHandler registers a function that is called with the key, value and reason whenever value is evicted
+// from any shard. Returns a function that unregisters the handler.
+func (c *ShardedLRU[K, V]) AddEvictionHandler(f func(K, V, EvictionReason)) (remove func()) {
+	removes := make([]func(), len(c.shards))
+
+	for i, s := range c.shards {
+		removes[i] = s.AddEvictionHandler(f)
+	}
+
+	return func() {
+		for _, r := range removes {
+			r()
+		}
+	}
+}
+
+// Flush deletes all elements in cache.
+func (c *ShardedLRU[K, V]) Flush() {
+	for _, s := range c.shards {
+		s.Flush()
+	}
+}
+
+// DeleteExpired deletes items by checking their expiration against current time. Calls onValueEvicted.
+func (c *ShardedLRU[K, V]) DeleteExpired() {
+	for _, s := range c.shards {
+		s.DeleteExpired()
+	}
+}
+
+// Get returns an item at given key and true if it was found.
+// It automatically extends the expiration if auto refresh is true.
+func (c *ShardedLRU[K, V]) Get(key K) (V, bool) {
+	return c.Shard(key).Get(key)
+}
+
+// GetOrLoad returns an item at given key or loads it through loader if it doesn't exist. See LRU.GetOrLoad.
+func (c *ShardedLRU[K, V]) GetOrLoad(ctx context.Context, key K, loader Loader[V]) (V, error) {
+	return c.Shard(key).GetOrLoad(ctx, key, loader)
+}
+
+// SetRefresher registers a function used to refresh stale values in background in all shards.
+// See LRU.SetRefresher.
+func (c *ShardedLRU[K, V]) SetRefresher(f func(ctx context.Context, key K) (V, time.Duration, error), onError func(key K, err error)) {
+	for _, s := range c.shards {
+		s.SetRefresher(f, onError)
+	}
+}
+
+// Refresh extends the expiration of given key. Returns true on success.
+func (c *ShardedLRU[K, V]) Refresh(key K) bool {
+	return c.Shard(key).Refresh(key)
+}
+
+// Set assigns a new value to an item at given key.
+func (c *ShardedLRU[K, V]) Set(key K, val V) {
+	c.Shard(key).Set(key, val)
+}
+
+func (c *ShardedLRU[K, V]) SetTTL(key K, val V, ttl time.Duration) {
+	c.Shard(key).SetTTL(key, val, ttl)
+}
+
+// Add assigns a new value to an item at given key if it doesn't exist.
+func (c *ShardedLRU[K, V]) Add(key K, val V) bool {
+	return c.Shard(key).Add(key, val)
+}
+
+func (c *ShardedLRU[K, V]) AddTTL(key K, val V, ttl time.Duration) bool {
+	return c.Shard(key).AddTTL(key, val, ttl)
+}
+
+// Delete removes an item at given key.
+func (c *ShardedLRU[K, V]) Delete(key K) bool {
+	return c.Shard(key).Delete(key)
+}
+
+// Contains returns true if item exists, false otherwise. Doesn't affect the order of recently used items.
+func (c *ShardedLRU[K, V]) Contains(key K) bool {
+	return c.Shard(key).Contains(key)
+}
+
+// Reduce iterates through values of all shards and calls func() with key, val and previous returned value.
+func (c *ShardedLRU[K, V]) Reduce(f func(key K, val V, total interface{}) interface{}) interface{} {
+	var total interface{}
+
+	for _, s := range c.shards {
+		total = s.reduce(f, total)
+	}
+
+	return total
+}
+
+// ShardedLRUCache describes an untyped sharded struct for caching. It is a thin wrapper over ShardedLRU with string keys.
+type ShardedLRUCache struct {
+	ShardedLRU[string, interface{}]
+}
+
+// NewShardedLRUCache creates and initializes a new sharded cache object.
+func NewShardedLRUCache(shards int, autoRefresh bool, opts ...Option) *ShardedLRUCache {
+	cache := ShardedLRUCache{}
+	cache.init(shards, util.Hash, autoRefresh, opts...)
+
+	return &cache
+}
+
+// Get returns an item at given key. It automatically extends the expiration if auto refresh is true. Returns the item or nil.
+func (c *ShardedLRUCache) Get(key string) interface{} {
+	val, _ := c.ShardedLRU.Get(key)
+	return val
+}
@@ -0,0 +1,102 @@
+package cache
+
+import (
+	"strconv"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestShardedLRUCache(t *testing.T) {
+	Convey("Given new sharded LRU cache", t, func() {
+		c := NewShardedLRUCache(4, true, WithCapacity(10))
+
+		Convey("capacity is split between shards", func() {
+			So(c.shards, ShouldHaveLength, 4)
+			So(c.Config().Capacity, ShouldEqual, 10)
+			So(c.shards[0].Config().Capacity, ShouldEqual, 3)
+		})
+		Convey("Get/Contains of non existent key returns nil/false", func() {
+			So(c.Get("key1"), ShouldBeNil)
+			So(c.Contains("key1"), ShouldBeFalse)
+		})
+		Convey("Set adds a new item to its shard that can be retrieved by Get", func() {
+			c.Set("key1", "value1")
+			So(c.Get("key1"), ShouldEqual, "value1")
+			So(c.Shard("key1").Len(), ShouldEqual, 1)
+			So(c.Len(), ShouldEqual, 1)
+			So(c.Add("key1", "value2"), ShouldBeFalse)
+			So(c.Refresh("key1"), ShouldBeTrue)
+			So(c.Delete("key1"), ShouldBeTrue)
+			So(c.Len(), ShouldEqual, 0)
+		})
+		Convey("with many items", func() {
+			var evicted int
+
+			c.OnValueEvicted(func(key string, val interface{}) {
+				evicted++
+			})
+
+			for i := 0; i < 100; i++ {
+				c.Set(strconv.Itoa(i), i)
+			}
+
+			Convey("global capacity is approximately enforced", func() {
+				So(c.Len(), ShouldBeLessThanOrEqualTo, 12)
+				So(c.Len(), ShouldBeGreaterThan, 0)
+			})
+			Convey("Reduce aggregates all shards", func() {
+				total := c.Reduce(func(key string, val interface{}, total interface{}) interface{} {
+					if total == nil {
+						return 1
+					}
+					return total.(int) + 1
+				})
+				So(total, ShouldEqual, c.Len())
+			})
+			Convey("capacity evictions are passed to handler", func() {
+				So(evicted, ShouldEqual, 100-c.Len())
+			})
+			Convey("Flush removes all elements", func() {
+				l := c.Len()
+				evicted = 0
+				c.Flush()
+				So(c.Len(), ShouldEqual, 0)
+				So(evicted, ShouldEqual, l)
+			})
+		})
+
+		c.StopJanitor()
+	})
+}
+
+func BenchmarkParallelGet(b *testing.B) {
+	const size = 1024
+
+	caches := map[string]interface {
+		Set(string, interface{})
+		Get(string) interface{}
+		StopJanitor()
+	}{
+		"single":  NewLRUCache(true),
+		"sharded": NewShardedLRUCache(32, true),
+	}
+
+	for name, c := range caches {
+		for i := 0; i < size; i++ {
+			c.Set(strconv.Itoa(i), i)
+		}
+
+		b.Run(name, func(b *testing.B) {
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					c.Get(strconv.Itoa(i % size))
+					i++
+				}
+			})
+		})
+
+		c.StopJanitor()
+	}
+}
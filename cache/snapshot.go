@@ -0,0 +1,182 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/vmihailenco/msgpack/v4"
+)
+
+const snapshotVersion = 1
+
+// Codec is used to serialize keys and values in cache snapshots.
+type Codec struct {
+	Marshal   func(interface{}) ([]byte, error)
+	Unmarshal func([]byte, interface{}) error
+}
+
+// MsgpackCodec serializes snapshot keys and values with msgpack.
+var MsgpackCodec = &Codec{
+	Marshal:   msgpack.Marshal,
+	Unmarshal: msgpack.Unmarshal,
+}
+
+type snapshotEntry struct {
+	Key        []byte
+	Value      []byte
+	Expiration int64
+	TTL        time.Duration
+}
+
+// Snapshot writes all keys, values and their expiration times to w using codec.
+// Expiration times are stored as absolute timestamps so time spent before restoring counts towards ttl.
+func (c *Base[K]) Snapshot(w io.Writer, codec *Codec) error {
+	var (
+		entries []*snapshotEntry
+		err     error
+	)
+
+	c.mu.RLock()
+	entries = make([]*snapshotEntry, 0, c.index.Len())
+
+	c.index.Range(func(vi *valuesItem[K]) {
+		if err != nil {
+			return
+		}
+
+		e := &snapshotEntry{Expiration: vi.item.expiration, TTL: vi.item.ttl}
+
+		if e.Key, err = codec.Marshal(vi.key); err != nil {
+			return
+		}
+
+		if e.Value, err = codec.Marshal(vi.item.object); err != nil {
+			return
+		}
+
+		entries = append(entries, e)
+	})
+	c.mu.RUnlock()
+
+	if err != nil {
+		return err
+	}
+
+	enc := msgpack.NewEncoder(w)
+
+	if err := enc.EncodeInt(snapshotVersion); err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// readSnapshot decodes entries written by Snapshot and calls f with decoded key for each one that hasn't expired yet.
+func (c *Base[K]) readSnapshot(r io.Reader, codec *Codec, f func(key K, e *snapshotEntry) error) error {
+	dec := msgpack.NewDecoder(r)
+
+	version, err := dec.DecodeInt()
+	if err != nil {
+		return err
+	}
+
+	if version != snapshotVersion {
+		return fmt.Errorf("cache: unsupported snapshot version %d", version)
+	}
+
+	for {
+		var e snapshotEntry
+
+		if err := dec.Decode(&e); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+
+			return err
+		}
+
+		if e.Expiration <= time.Now().UnixNano() {
+			continue
+		}
+
+		var key K
+
+		if err := codec.Unmarshal(e.Key, &key); err != nil {
+			return err
+		}
+
+		if err := f(key, &e); err != nil {
+			return err
+		}
+	}
+}
+
+// Restore reads keys, values and their expiration times from snapshot created by Snapshot.
+// Entries that have already expired are skipped and keys that already exist in cache are kept intact.
+// Values are decoded into V so untyped caches get values as decoded by codec.
+func (c *LRU[K, V]) Restore(r io.Reader, codec *Codec) error {
+	return c.readSnapshot(r, codec, func(key K, e *snapshotEntry) error {
+		var val V
+
+		if err := codec.Unmarshal(e.Value, &val); err != nil {
+			return err
+		}
+
+		var evicted []*keyValue[K]
+
+		c.mu.Lock()
+		_, exists := c.valueMap[key]
+		if !exists {
+			evicted = c.setItem(key, &Item{object: val, expiration: e.Expiration, ttl: e.TTL})
+		}
+		c.mu.Unlock()
+
+		if !exists {
+			c.notify(Event[K, V]{Type: EventSet, Key: key, Value: val})
+		}
+
+		c.handleEviction(evicted...)
+
+		return nil
+	})
+}
+
+// Restore reads keys, values and their expiration times from snapshot created by Snapshot.
+// Entries that have already expired are skipped and values that already exist in cache are kept intact.
+// Values are decoded into V so untyped caches get values as decoded by codec.
+func (c *LRUSet[K, V]) Restore(r io.Reader, codec *Codec) error {
+	return c.readSnapshot(r, codec, func(key K, e *snapshotEntry) error {
+		var val V
+
+		if err := codec.Unmarshal(e.Value, &val); err != nil {
+			return err
+		}
+
+		var evicted []*keyValue[K]
+
+		c.mu.Lock()
+
+		curVal, ok := c.valueMap[key]
+		if ok {
+			_, ok = curVal.(map[V]*Item)[val]
+		}
+
+		if !ok {
+			evicted = c.addItem(key, val, &Item{object: val, expiration: e.Expiration, ttl: e.TTL})
+		}
+
+		c.mu.Unlock()
+
+		c.handleEviction(evicted...)
+
+		return nil
+	})
+}
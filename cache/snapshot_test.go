@@ -0,0 +1,88 @@
+package cache
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/vmihailenco/msgpack/v4"
+)
+
+func TestSnapshot(t *testing.T) {
+	type value struct {
+		Name  string
+		Count int
+	}
+
+	Convey("Given typed LRU cache with some values", t, func() {
+		c := NewLRU[string, value](false)
+		c.Set("key1", value{Name: "a", Count: 1})
+		c.SetTTL("key2", value{Name: "b", Count: 2}, time.Hour)
+		c.Set("key3", value{Name: "c", Count: 3})
+		c.valueMap["key3"].(*Item).expiration = 1
+
+		var buf bytes.Buffer
+
+		So(c.Snapshot(&buf, MsgpackCodec), ShouldBeNil)
+
+		Convey("Restore loads values that haven't expired with their expiration", func() {
+			c2 := NewLRU[string, value](false)
+			So(c2.Restore(&buf, MsgpackCodec), ShouldBeNil)
+			So(c2.Len(), ShouldEqual, 2)
+
+			v, ok := c2.Get("key1")
+			So(ok, ShouldBeTrue)
+			So(v, ShouldResemble, value{Name: "a", Count: 1})
+			So(c2.Contains("key3"), ShouldBeFalse)
+			So(c2.valueMap["key2"].(*Item).expiration, ShouldEqual, c.valueMap["key2"].(*Item).expiration)
+			So(c2.valueMap["key2"].(*Item).ttl, ShouldEqual, time.Hour)
+
+			c2.StopJanitor()
+		})
+		Convey("Restore keeps existing keys intact", func() {
+			c2 := NewLRU[string, value](false)
+			c2.Set("key1", value{Name: "new"})
+			So(c2.Restore(&buf, MsgpackCodec), ShouldBeNil)
+
+			v, _ := c2.Get("key1")
+			So(v.Name, ShouldEqual, "new")
+
+			c2.StopJanitor()
+		})
+		Convey("Restore fails on unsupported version", func() {
+			b, _ := msgpack.Marshal(snapshotVersion + 1)
+			So(c.Restore(bytes.NewReader(b), MsgpackCodec), ShouldBeError)
+		})
+		Convey("Restore fails on corrupted snapshot", func() {
+			b := buf.Bytes()
+			So(c.Restore(bytes.NewReader(b[:len(b)-1]), MsgpackCodec), ShouldBeError)
+		})
+
+		c.StopJanitor()
+	})
+
+	Convey("Given LRU set cache with some values", t, func() {
+		c := NewLRUSetCache()
+		c.Add("key1", "a")
+		c.Add("key1", "b")
+		c.Add("key2", "c")
+
+		var buf bytes.Buffer
+
+		So(c.Snapshot(&buf, MsgpackCodec), ShouldBeNil)
+
+		Convey("Restore loads all values", func() {
+			c2 := NewLRUSetCache()
+			c2.Add("key1", "a")
+			So(c2.Restore(&buf, MsgpackCodec), ShouldBeNil)
+			So(c2.Len(), ShouldEqual, 3)
+			So(c2.Get("key1"), ShouldHaveLength, 2)
+			So(c2.Contains("key2", "c"), ShouldBeTrue)
+
+			c2.StopJanitor()
+		})
+
+		c.StopJanitor()
+	})
+}
@@ -0,0 +1,169 @@
+package cache
+
+import (
+	"context"
+	"sync/atomic"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	"github.com/Syncano/pkg-go/v2/util"
+)
+
+// EvictionReason describes why a value was removed from cache.
+type EvictionReason int
+
+const (
+	// EvictionReasonExpired marks values removed after their ttl has passed.
+	EvictionReasonExpired EvictionReason = iota
+	// EvictionReasonCapacity marks values removed to keep cache within its capacity.
+	EvictionReasonCapacity
+	// EvictionReasonDeleted marks values removed explicitly by Delete.
+	EvictionReasonDeleted
+	// EvictionReasonFlushed marks values removed by Flush.
+	EvictionReasonFlushed
+	// EvictionReasonReplaced marks values overwritten by a new value for the same key.
+	EvictionReasonReplaced
+
+	evictionReasonCount = iota
+)
+
+var evictionReasonNames = [evictionReasonCount]string{
+	EvictionReasonExpired:  "expired",
+	EvictionReasonCapacity: "capacity",
+	EvictionReasonDeleted:  "deleted",
+	EvictionReasonFlushed:  "flushed",
+	EvictionReasonReplaced: "replaced",
+}
+
+func (r EvictionReason) String() string {
+	if r < 0 || int(r) >= len(evictionReasonNames) {
+		return "unknown"
+	}
+
+	return evictionReasonNames[r]
+}
+
+// Stats holds a snapshot of cache statistics.
+type Stats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions map[EvictionReason]uint64
+	Len       int
+	Weight    int64
+}
+
+type statsCounters struct {
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	evictions [evictionReasonCount]atomic.Uint64
+}
+
+var (
+	// KeyCacheName is a tag key holding cache name set through WithMetrics.
+	KeyCacheName = tag.MustNewKey("cache")
+	// KeyEvictionReason is a tag key holding eviction reason.
+	KeyEvictionReason = tag.MustNewKey("reason")
+
+	MeasureHits      = stats.Int64("cache/hits", "Number of cache hits", stats.UnitDimensionless)
+	MeasureMisses    = stats.Int64("cache/misses", "Number of cache misses", stats.UnitDimensionless)
+	MeasureEvictions = stats.Int64("cache/evictions", "Number of values removed from cache", stats.UnitDimensionless)
+	MeasureSize      = stats.Int64("cache/size", "Change of number of items in cache", stats.UnitDimensionless)
+
+	HitsView = &view.View{
+		Measure:     MeasureHits,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{KeyCacheName},
+	}
+	MissesView = &view.View{
+		Measure:     MeasureMisses,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{KeyCacheName},
+	}
+	EvictionsView = &view.View{
+		Measure:     MeasureEvictions,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{KeyCacheName, KeyEvictionReason},
+	}
+	// SizeView sums size changes so it reports current number of items in cache.
+	SizeView = &view.View{
+		Measure:     MeasureSize,
+		Aggregation: view.Sum(),
+		TagKeys:     []tag.Key{KeyCacheName},
+	}
+
+	// DefaultViews are the views registered by caches created with WithMetrics.
+	DefaultViews = []*view.View{HitsView, MissesView, EvictionsView, SizeView}
+
+	views util.ViewsRegistry
+)
+
+type metrics struct {
+	ctx       context.Context
+	reasonCtx [evictionReasonCount]context.Context
+}
+
+func newMetrics(name string) *metrics {
+	views.Register(DefaultViews...)
+
+	m := &metrics{ctx: util.TagContext(KeyCacheName, name)}
+
+	for i := range m.reasonCtx {
+		ctx, err := tag.New(m.ctx, tag.Upsert(KeyEvictionReason, EvictionReason(i).String()))
+		util.Must(err)
+
+		m.reasonCtx[i] = ctx
+	}
+
+	return m
+}
+
+// Stats returns a snapshot of cache statistics.
+func (c *Base[K]) Stats() *Stats {
+	s := &Stats{
+		Hits:      c.stats.hits.Load(),
+		Misses:    c.stats.misses.Load(),
+		Evictions: make(map[EvictionReason]uint64, evictionReasonCount),
+		Len:       c.Len(),
+		Weight:    c.Weight(),
+	}
+
+	for i := range c.stats.evictions {
+		s.Evictions[EvictionReason(i)] = c.stats.evictions[i].Load()
+	}
+
+	return s
+}
+
+func (c *Base[K]) recordLookup(hit bool) {
+	if hit {
+		c.stats.hits.Add(1)
+	} else {
+		c.stats.misses.Add(1)
+	}
+
+	if c.metrics == nil {
+		return
+	}
+
+	if hit {
+		stats.Record(c.metrics.ctx, MeasureHits.M(1))
+	} else {
+		stats.Record(c.metrics.ctx, MeasureMisses.M(1))
+	}
+}
+
+func (c *Base[K]) recordEviction(reason EvictionReason) {
+	c.stats.evictions[reason].Add(1)
+
+	if c.metrics != nil {
+		stats.Record(c.metrics.reasonCtx[reason], MeasureEvictions.M(1))
+	}
+}
+
+func (c *Base[K]) recordSize(delta int64) {
+	if c.metrics != nil {
+		stats.Record(c.metrics.ctx, MeasureSize.M(delta))
+	}
+}
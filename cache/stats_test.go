@@ -0,0 +1,92 @@
+package cache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+// retrieveRows returns rows of view tagged with given cache name as views are shared by all caches in process.
+func retrieveRows(viewName, name string) []*view.Row {
+	rows, err := view.RetrieveData(viewName)
+	So(err, ShouldBeNil)
+
+	var ret []*view.Row
+
+	for _, r := range rows {
+		for _, t := range r.Tags {
+			if t == (tag.Tag{Key: KeyCacheName, Value: name}) {
+				ret = append(ret, r)
+			}
+		}
+	}
+
+	return ret
+}
+
+func TestStats(t *testing.T) {
+	Convey("Given new LRU cache", t, func() {
+		c := NewLRUCache(false, WithCapacity(2))
+
+		Convey("Stats counts hits, misses and evictions by reason", func() {
+			c.Get("key1")
+			c.Set("key1", "value1")
+			c.Get("key1")
+			c.Set("key1", "value2")
+			c.Set("key2", "value2")
+			c.Set("key3", "value3")
+			c.Delete("key3")
+			c.Set("key4", "value4")
+			c.Flush()
+
+			s := c.Stats()
+			So(s.Hits, ShouldEqual, 1)
+			So(s.Misses, ShouldEqual, 1)
+			So(s.Len, ShouldEqual, 0)
+			So(s.Evictions, ShouldResemble, map[EvictionReason]uint64{
+				EvictionReasonExpired:  0,
+				EvictionReasonCapacity: 1,
+				EvictionReasonDeleted:  1,
+				EvictionReasonFlushed:  2,
+				EvictionReasonReplaced: 1,
+			})
+		})
+		Convey("EvictionReason has a readable name", func() {
+			So(EvictionReasonCapacity.String(), ShouldEqual, "capacity")
+			So(EvictionReason(-1).String(), ShouldEqual, "unknown")
+		})
+
+		c.StopJanitor()
+	})
+
+	Convey("Given new LRU cache with metrics", t, func() {
+		name := fmt.Sprintf("stats_test_%d", time.Now().UnixNano())
+		c := NewLRUCache(false, WithMetrics(name))
+
+		Convey("statistics are recorded as OpenCensus views", func() {
+			c.Set("key1", "value1")
+			c.Set("key2", "value2")
+			c.Get("key1")
+			c.Get("key3")
+			c.Delete("key2")
+
+			rows := retrieveRows(HitsView.Name, name)
+			So(rows, ShouldHaveLength, 1)
+			So(rows[0].Data.(*view.CountData).Value, ShouldEqual, 1)
+
+			rows = retrieveRows(EvictionsView.Name, name)
+			So(rows, ShouldHaveLength, 1)
+			So(rows[0].Tags, ShouldHaveLength, 2)
+
+			rows = retrieveRows(SizeView.Name, name)
+			So(rows, ShouldHaveLength, 1)
+			So(rows[0].Data.(*view.SumData).Value, ShouldEqual, 1)
+		})
+
+		c.StopJanitor()
+	})
+}
@@ -0,0 +1,222 @@
+package cache
+
+import (
+	"strings"
+	"sync"
+)
+
+// EventType describes a kind of change in cache.
+type EventType int
+
+const (
+	// EventSet is emitted when a new value is stored at key.
+	EventSet EventType = iota
+	// EventRefresh is emitted when expiration of a value is extended.
+	EventRefresh
+	// EventEvict is emitted when a value is removed from cache, see Reason for details.
+	EventEvict
+)
+
+var eventTypeNames = [...]string{
+	EventSet:     "set",
+	EventRefresh: "refresh",
+	EventEvict:   "evict",
+}
+
+func (t EventType) String() string {
+	if t < 0 || int(t) >= len(eventTypeNames) {
+		return "unknown"
+	}
+
+	return eventTypeNames[t]
+}
+
+// Event describes a single change of value at key. Reason is only meaningful for EventEvict.
+type Event[K comparable, V any] struct {
+	Type   EventType
+	Key    K
+	Value  V
+	Reason EvictionReason
+}
+
+// CacheEvent is an Event of LRUCache.
+type CacheEvent = Event[string, interface{}]
+
+// OverflowPolicy decides what happens with events sent to watcher whose buffer is full.
+type OverflowPolicy int
+
+const (
+	// OverflowDropOldest discards the oldest buffered event to make room for a new one.
+	OverflowDropOldest OverflowPolicy = iota
+	// OverflowDropNewest discards the new event.
+	OverflowDropNewest
+	// OverflowBlock blocks cache operation that emitted the event until there is room in buffer or watch is canceled.
+	OverflowBlock
+)
+
+// WatchConfig holds settable config for watch.
+type WatchConfig struct {
+	BufferSize int
+	Overflow   OverflowPolicy
+}
+
+var DefaultWatchConfig = WatchConfig{
+	BufferSize: 64,
+	Overflow:   OverflowDropOldest,
+}
+
+type WatchOption func(*WatchConfig)
+
+// WithBufferSize sets number of events buffered for a watcher. Sizes below 1 are raised to 1 as dropping
+// the oldest event needs a buffer to drop it from.
+func WithBufferSize(n int) WatchOption {
+	return func(config *WatchConfig) {
+		if n < 1 {
+			n = 1
+		}
+
+		config.BufferSize = n
+	}
+}
+
+// WithOverflowPolicy sets what happens with events when watcher's buffer is full.
+func WithOverflowPolicy(p OverflowPolicy) WatchOption {
+	return func(config *WatchConfig) {
+		config.Overflow = p
+	}
+}
+
+type watcher[K comparable, V any] struct {
+	match    func(K) bool
+	overflow OverflowPolicy
+	ch       chan Event[K, V]
+
+	mu       sync.Mutex
+	closed   bool
+	done     chan struct{}
+	doneOnce sync.Once
+}
+
+func (w *watcher[K, V]) send(e Event[K, V]) {
+	if !w.match(e.Key) {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return
+	}
+
+	switch w.overflow {
+	case OverflowBlock:
+		select {
+		case w.ch <- e:
+		case <-w.done:
+		}
+	case OverflowDropNewest:
+		select {
+		case w.ch <- e:
+		default:
+		}
+	default:
+		for {
+			select {
+			case w.ch <- e:
+				return
+			default:
+			}
+
+			// Make room by discarding the oldest event unless it has just been consumed.
+			select {
+			case <-w.ch:
+			default:
+			}
+		}
+	}
+}
+
+// stop unblocks pending sends and closes the channel once they are done.
+func (w *watcher[K, V]) stop() {
+	w.doneOnce.Do(func() {
+		close(w.done)
+	})
+
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.ch)
+	}
+	w.mu.Unlock()
+}
+
+// WatchFunc returns a channel of set, refresh and evict events of keys for which match returns true.
+// Channel is closed after returned cancel function is called. Events are sent after cache lock is released
+// so events of concurrent operations may be received in any order.
+func (c *LRU[K, V]) WatchFunc(match func(K) bool, opts ...WatchOption) (<-chan Event[K, V], func()) {
+	cfg := DefaultWatchConfig
+
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	w := &watcher[K, V]{
+		match:    match,
+		overflow: cfg.Overflow,
+		ch:       make(chan Event[K, V], cfg.BufferSize),
+		done:     make(chan struct{}),
+	}
+
+	c.muWatch.Lock()
+	if len(c.watchers) == 0 {
+		c.removeWatchHandler = c.Base.AddEvictionHandler(func(key K, val interface{}, reason EvictionReason) {
+			v, _ := val.(V)
+			c.notify(Event[K, V]{Type: EventEvict, Key: key, Value: v, Reason: reason})
+		})
+	}
+
+	c.watchers = append(c.watchers, w)
+	c.muWatch.Unlock()
+
+	return w.ch, func() {
+		// Stop first so that a send blocked in eviction handler returns before handler is removed,
+		// removing it waits for handlers in progress.
+		w.stop()
+
+		c.muWatch.Lock()
+
+		for i, cur := range c.watchers {
+			if cur == w {
+				c.watchers = append(c.watchers[:i:i], c.watchers[i+1:]...)
+
+				if len(c.watchers) == 0 {
+					c.removeWatchHandler()
+				}
+
+				break
+			}
+		}
+
+		c.muWatch.Unlock()
+	}
+}
+
+func (c *LRU[K, V]) notify(e Event[K, V]) {
+	c.muWatch.RLock()
+	watchers := c.watchers
+	c.muWatch.RUnlock()
+
+	for _, w := range watchers {
+		w.send(e)
+	}
+}
+
+// Watch returns a channel of set, refresh and evict events of keys starting with prefix.
+// By default up to 64 events are buffered and the oldest ones are dropped when buffer is full.
+// Channel is closed after returned cancel function is called.
+func (c *LRUCache) Watch(prefix string, opts ...WatchOption) (<-chan CacheEvent, func()) {
+	return c.WatchFunc(func(key string) bool {
+		return strings.HasPrefix(key, prefix)
+	}, opts...)
+}
@@ -0,0 +1,163 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestWatch(t *testing.T) {
+	Convey("Given LRU cache with auto refresh and a watcher", t, func() {
+		c := NewLRUCache(true, WithCapacity(2))
+		events, cancel := c.Watch("user:")
+
+		Convey("set, refresh and evict events of matching keys are emitted", func() {
+			c.Set("user:1", "a")
+			c.Set("other", "x")
+			c.Get("user:1")
+			c.Refresh("user:1")
+			c.Add("user:1", "b")
+			c.Set("user:1", "c")
+			c.Delete("user:1")
+
+			So(<-events, ShouldResemble, CacheEvent{Type: EventSet, Key: "user:1", Value: "a"})
+			So(<-events, ShouldResemble, CacheEvent{Type: EventRefresh, Key: "user:1", Value: "a"})
+			So(<-events, ShouldResemble, CacheEvent{Type: EventRefresh, Key: "user:1", Value: "a"})
+			So(<-events, ShouldResemble, CacheEvent{Type: EventRefresh, Key: "user:1", Value: "a"})
+			So(<-events, ShouldResemble, CacheEvent{Type: EventEvict, Key: "user:1", Value: "a", Reason: EvictionReasonReplaced})
+			So(<-events, ShouldResemble, CacheEvent{Type: EventSet, Key: "user:1", Value: "c"})
+			So(<-events, ShouldResemble, CacheEvent{Type: EventEvict, Key: "user:1", Value: "c", Reason: EvictionReasonDeleted})
+			So(events, ShouldBeEmpty)
+		})
+		Convey("capacity evictions are emitted after set", func() {
+			c.Set("user:1", "a")
+			c.Set("user:2", "b")
+			c.Set("user:3", "c")
+
+			<-events
+			<-events
+			So(<-events, ShouldResemble, CacheEvent{Type: EventSet, Key: "user:3", Value: "c"})
+			So(<-events, ShouldResemble, CacheEvent{Type: EventEvict, Key: "user:1", Value: "a", Reason: EvictionReasonCapacity})
+		})
+		Convey("cancel closes channel and stops events", func() {
+			cancel()
+			c.Set("user:1", "a")
+
+			_, ok := <-events
+			So(ok, ShouldBeFalse)
+			So(c.watchers, ShouldBeEmpty)
+			So(c.handlers, ShouldBeEmpty)
+		})
+
+		cancel()
+		c.StopJanitor()
+	})
+
+	Convey("Given LRU cache", t, func() {
+		c := NewLRUCache(false)
+
+		Convey("drop oldest policy keeps the newest events", func() {
+			events, cancel := c.Watch("", WithBufferSize(2))
+			defer cancel()
+
+			c.Set("1", 1)
+			c.Set("2", 2)
+			c.Set("3", 3)
+
+			So((<-events).Key, ShouldEqual, "2")
+			So((<-events).Key, ShouldEqual, "3")
+		})
+		Convey("drop newest policy keeps the oldest events", func() {
+			events, cancel := c.Watch("", WithBufferSize(2), WithOverflowPolicy(OverflowDropNewest))
+			defer cancel()
+
+			c.Set("1", 1)
+			c.Set("2", 2)
+			c.Set("3", 3)
+
+			So((<-events).Key, ShouldEqual, "1")
+			So((<-events).Key, ShouldEqual, "2")
+			So(events, ShouldBeEmpty)
+		})
+		Convey("block policy waits for subscriber", func() {
+			events, cancel := c.Watch("", WithBufferSize(1), WithOverflowPolicy(OverflowBlock))
+			defer cancel()
+
+			c.Set("1", 1)
+
+			done := make(chan struct{})
+			go func() {
+				c.Set("2", 2)
+				close(done)
+			}()
+
+			time.Sleep(10 * time.Millisecond)
+
+			select {
+			case <-done:
+				So("set should block", ShouldBeEmpty)
+			default:
+			}
+
+			So((<-events).Key, ShouldEqual, "1")
+			<-done
+			So((<-events).Key, ShouldEqual, "2")
+		})
+		Convey("cancel unblocks blocked operation", func() {
+			_, cancel := c.Watch("", WithBufferSize(1), WithOverflowPolicy(OverflowBlock))
+
+			c.Set("1", 1)
+
+			done := make(chan struct{})
+			go func() {
+				c.Set("2", 2)
+				close(done)
+			}()
+
+			time.Sleep(10 * time.Millisecond)
+			cancel()
+			<-done
+		})
+		Convey("cancel unblocks blocked eviction", func() {
+			_, cancel := c.Watch("", WithBufferSize(1), WithOverflowPolicy(OverflowBlock))
+
+			c.Set("1", 1)
+
+			done := make(chan struct{})
+			go func() {
+				c.Delete("1")
+				close(done)
+			}()
+
+			time.Sleep(10 * time.Millisecond)
+
+			canceled := make(chan struct{})
+			go func() {
+				cancel()
+				close(canceled)
+			}()
+
+			select {
+			case <-canceled:
+			case <-time.After(time.Second):
+				So("cancel should not block", ShouldBeEmpty)
+			}
+
+			<-done
+			So(c.handlers, ShouldBeEmpty)
+		})
+		Convey("zero buffer size is raised to 1", func() {
+			events, cancel := c.Watch("", WithBufferSize(0))
+			defer cancel()
+
+			c.Set("1", 1)
+			c.Set("2", 2)
+
+			So(cap(events), ShouldEqual, 1)
+			So((<-events).Key, ShouldEqual, "2")
+		})
+
+		c.StopJanitor()
+	})
+}
@@ -0,0 +1,73 @@
+package limiter
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Handle is a lock acquired on a semaphore along with semaphore state at the time of acquiring.
+type Handle struct {
+	*LockInfo
+
+	key      string
+	ids      []string
+	units    int
+	acquired time.Time
+	release  func()
+	once     sync.Once
+	timer    *time.Timer
+}
+
+func newHandle(key string, info *LockInfo, release func()) *Handle {
+	return &Handle{
+		LockInfo: info,
+		key:      key,
+		acquired: time.Now(),
+		release:  release,
+	}
+}
+
+// watch force releases handle that is held for longer than maxHold.
+func (h *Handle) watch(maxHold time.Duration, logger *zap.Logger) {
+	if maxHold <= 0 {
+		return
+	}
+
+	h.timer = time.AfterFunc(maxHold, func() {
+		if h.doRelease() {
+			logger.Warn("Lock held for too long, force releasing",
+				zap.String("key", h.key),
+				zap.Duration("held", time.Since(h.acquired)),
+			)
+		}
+	})
+}
+
+func (h *Handle) doRelease() (released bool) {
+	h.once.Do(func() {
+		h.release()
+
+		released = true
+	})
+
+	return
+}
+
+// Release returns lock to semaphore pool. It is safe to call it multiple times and after lock was force released.
+func (h *Handle) Release() {
+	if h.doRelease() && h.timer != nil {
+		h.timer.Stop()
+	}
+}
+
+// Units returns number of units of capacity held.
+func (h *Handle) Units() int {
+	return h.units
+}
+
+// Acquired returns time when lock was acquired.
+func (h *Handle) Acquired() time.Time {
+	return h.acquired
+}
@@ -0,0 +1,20 @@
+package limiter
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Handler returns echo handler that responds with JSON of active semaphores of limiter by their keys.
+// It is meant for debugging and should not be exposed publicly.
+func Handler(l Locker) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		active := l.Active()
+		if active == nil {
+			return echo.NewHTTPError(http.StatusServiceUnavailable, "Limiter state is unavailable.")
+		}
+
+		return c.JSON(http.StatusOK, active)
+	}
+}
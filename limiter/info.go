@@ -1,11 +1,20 @@
 package limiter
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 type LockInfo struct {
-	Capacity int
-	Taken    int
-	Queued   int
+	// Capacity is a configured limit of semaphore.
+	Capacity int `json:"capacity"`
+	// EffectiveCapacity is a number of locks that can be held at the moment. It is higher than Capacity while
+	// semaphore drains after its capacity was lowered.
+	EffectiveCapacity int `json:"effective_capacity"`
+	Taken             int `json:"taken"`
+	Queued            int `json:"queued"`
+	// OldestHolderAge is a time for which the oldest lock has been held.
+	OldestHolderAge time.Duration `json:"oldest_holder_age"`
 }
 
 func (li *LockInfo) String() string {
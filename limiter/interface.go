@@ -0,0 +1,22 @@
+package limiter
+
+import "context"
+
+//go:generate go run github.com/vektra/mockery/cmd/mockery -name Locker
+
+// Locker provides semaphore-like locking of keys with limited concurrency.
+type Locker interface {
+	Lock(ctx context.Context, key string, limit int, opts ...LockOption) (*Handle, error)
+	// Deprecated: use Handle.Release instead.
+	Unlock(key string, limit int)
+	SetLimit(key string, limit int)
+	Info(key string) *LockInfo
+	Active() map[string]*LockInfo
+	Shutdown()
+}
+
+// Assert that both limiters are compatible with our interface.
+var (
+	_ Locker = (*Limiter)(nil)
+	_ Locker = (*RedisLimiter)(nil)
+)
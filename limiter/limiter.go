@@ -3,18 +3,20 @@ package limiter
 import (
 	"context"
 	"errors"
-	"fmt"
 	"sync"
-	"sync/atomic"
 	"time"
 
+	"github.com/go-redis/redis/v7"
+	"go.uber.org/zap"
+
 	"github.com/Syncano/pkg-go/v2/cache"
 )
 
 // Limiter allows semaphore rate limiting functionality.
 type Limiter struct {
 	mu       sync.Mutex
-	channels *cache.LRUCache // string->channel
+	channels *cache.LRU[string, *lockData]
+	metrics  *metrics
 
 	cfg Config
 }
@@ -23,14 +25,34 @@ type Limiter struct {
 type Config struct {
 	Queue int
 	TTL   time.Duration
+
+	// Weights of priority classes sharing capacity of a key. Classes without weight get weight of 1.
+	Weights map[Priority]int
+
+	// MetricsName enables OpenCensus metrics tagged with it when set.
+	MetricsName string
+
+	// MaxHold is a duration after which locks are force released. Zero disables it.
+	MaxHold time.Duration
+	Logger  *zap.Logger
+
+	// Redis, LeaseTTL and PollInterval are only used by RedisLimiter.
+	Redis        redis.Cmdable
+	KeyPrefix    string
+	LeaseTTL     time.Duration
+	PollInterval time.Duration
 }
 
 type Option func(*Config)
 
 // DefaultConfig holds default options values for limiter.
 var DefaultConfig = Config{
-	Queue: 100,
-	TTL:   10 * time.Minute,
+	Queue:        100,
+	TTL:          10 * time.Minute,
+	KeyPrefix:    "limiter",
+	LeaseTTL:     30 * time.Second,
+	PollInterval: 50 * time.Millisecond,
+	Logger:       zap.NewNop(),
 }
 
 func WithQueue(size int) Option {
@@ -45,19 +67,113 @@ func WithTTL(ttl time.Duration) Option {
 	}
 }
 
-type lockData struct {
-	ch    chan struct{}
-	queue int32
+// WithPriorityWeights sets weights of priority classes. When waiters of multiple classes are queued, each class
+// is granted locks proportionally to its weight, e.g. with weights 3 and 1, three interactive waiters are
+// let through for each batch one.
+func WithPriorityWeights(weights map[Priority]int) Option {
+	return func(config *Config) {
+		config.Weights = weights
+	}
+}
+
+// WithMetrics enables recording of lock wait time, rejections and cancellations as OpenCensus measures tagged with
+// given limiter name. See DefaultViews.
+func WithMetrics(name string) Option {
+	return func(config *Config) {
+		config.MetricsName = name
+	}
+}
+
+// WithMaxHold makes locks held for longer than d force released and logged, see WithLogger.
+func WithMaxHold(d time.Duration) Option {
+	return func(config *Config) {
+		config.MaxHold = d
+	}
+}
+
+// WithLogger sets logger used to report force released locks and failed lease renewals. Nil logger is ignored.
+func WithLogger(logger *zap.Logger) Option {
+	return func(config *Config) {
+		if logger != nil {
+			config.Logger = logger
+		}
+	}
+}
+
+// WithRedis makes NewLocker create RedisLimiter that shares limits through given redis client.
+func WithRedis(cli redis.Cmdable) Option {
+	return func(config *Config) {
+		config.Redis = cli
+	}
+}
+
+// WithKeyPrefix sets prefix of keys used by RedisLimiter.
+func WithKeyPrefix(prefix string) Option {
+	return func(config *Config) {
+		config.KeyPrefix = prefix
+	}
+}
+
+// WithLease sets how long RedisLimiter lock is kept without being renewed and how often it is retried when full.
+// Locks held by a crashed process are released after lease ttl.
+func WithLease(ttl, pollInterval time.Duration) Option {
+	return func(config *Config) {
+		config.LeaseTTL = ttl
+		config.PollInterval = pollInterval
+	}
+}
+
+// Priority identifies a class of waiters. Waiters within a class are served in FIFO order.
+type Priority int
+
+// PriorityDefault is a priority of Lock calls without WithPriority.
+const PriorityDefault Priority = 0
+
+type lockConfig struct {
+	priority Priority
+	units    int
+}
+
+type LockOption func(*lockConfig)
+
+// WithPriority queues Lock call in given priority class, see WithPriorityWeights.
+func WithPriority(p Priority) LockOption {
+	return func(config *lockConfig) {
+		config.priority = p
+	}
 }
 
-const lockTemplate = "%s:%d"
+// WithUnits makes Lock acquire n units of capacity at once. They are all returned by Handle.Release.
+// Waiters are granted in order so a large request is not starved by smaller ones queued after it.
+func WithUnits(n int) LockOption {
+	return func(config *lockConfig) {
+		config.units = n
+	}
+}
 
 var (
 	// ErrMaxQueueSizeReached signals that queue has overflown.
 	ErrMaxQueueSizeReached = errors.New("max queue size reached")
+	// ErrUnitsExceedLimit signals that more units were requested than limit of a key.
+	ErrUnitsExceedLimit = errors.New("units exceed limit")
 )
 
-// New initializes new limiter.
+// NewLocker creates RedisLimiter if redis client is configured (see WithRedis) or local Limiter otherwise.
+func NewLocker(opts ...Option) Locker {
+	cfg := DefaultConfig
+
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	if cfg.Redis != nil {
+		return NewRedis(cfg.Redis, opts...)
+	}
+
+	return New(opts...)
+}
+
+// New initializes new local limiter. Limits are enforced per process.
 func New(opts ...Option) *Limiter {
 	cfg := DefaultConfig
 
@@ -65,10 +181,11 @@ func New(opts ...Option) *Limiter {
 		opt(&cfg)
 	}
 
-	channels := cache.NewLRUCache(true, cache.WithTTL(cfg.TTL))
+	channels := cache.NewLRU[string, *lockData](true, cache.WithTTL(cfg.TTL))
 	l := &Limiter{
 		cfg:      cfg,
 		channels: channels,
+		metrics:  newMetrics(cfg.MetricsName),
 	}
 
 	return l
@@ -77,14 +194,11 @@ func New(opts ...Option) *Limiter {
 func (l *Limiter) createLock(key string, limit int) (lock *lockData) {
 	l.mu.Lock()
 
-	v := l.channels.Get(key)
-	if v == nil {
-		ch := make(chan struct{}, limit)
-		lock = &lockData{ch: ch}
+	lock, ok := l.channels.Get(key)
+	if !ok {
+		lock = newLockData(limit)
 
 		l.channels.Set(key, lock)
-	} else {
-		lock = v.(*lockData)
 	}
 
 	l.mu.Unlock()
@@ -92,62 +206,106 @@ func (l *Limiter) createLock(key string, limit int) (lock *lockData) {
 	return
 }
 
-// Lock tries to get a lock on a semaphore on key with limit.
-func (l *Limiter) Lock(ctx context.Context, key string, limit int) (*LockInfo, error) {
+// Lock tries to get a lock on a semaphore on key with limit. Returned handle has to be released after use.
+// Waiters are granted locks in FIFO order within their priority class.
+// If limit differs from current capacity of a semaphore, capacity is changed in place, see SetLimit.
+func (l *Limiter) Lock(ctx context.Context, key string, limit int, opts ...LockOption) (*Handle, error) {
 	if limit <= 0 {
 		return nil, ErrMaxQueueSizeReached
 	}
 
-	var lock *lockData
+	lcfg := lockConfig{units: 1}
 
-	key = fmt.Sprintf(lockTemplate, key, limit)
+	for _, opt := range opts {
+		opt(&lcfg)
+	}
+
+	if lcfg.units <= 0 {
+		lcfg.units = 1
+	}
 
-	v := l.channels.Get(key)
-	if v == nil {
+	lock, ok := l.channels.Get(key)
+	if !ok {
 		lock = l.createLock(key, limit)
-	} else {
-		lock = v.(*lockData)
 	}
 
-	defer atomic.AddInt32(&lock.queue, -1)
+	start := time.Now()
+	h, err := l.acquire(ctx, lock, key, limit, &lcfg)
+	l.metrics.recordLock(start, err)
 
-	if int(atomic.AddInt32(&lock.queue, 1)) > l.cfg.Queue {
-		return nil, ErrMaxQueueSizeReached
+	return h, err
+}
+
+// SetLimit changes capacity of an existing semaphore on key. When capacity is raised, queued waiters are let
+// through right away. When it is lowered, current holders keep their locks and new ones are granted only after
+// enough of them are released.
+func (l *Limiter) SetLimit(key string, limit int) {
+	if limit <= 0 {
+		return
 	}
 
-	select {
-	case lock.ch <- struct{}{}:
-		return &LockInfo{Capacity: limit, Taken: len(lock.ch)}, nil
-	case <-ctx.Done():
-		return nil, ctx.Err()
+	lock, ok := l.channels.Get(key)
+	if !ok {
+		return
 	}
+
+	lock.mu.Lock()
+	l.setCapacity(lock, key, limit)
+	lock.mu.Unlock()
 }
 
 func (l *Limiter) Info(key string) *LockInfo {
-	v := l.channels.Get(key)
-	if v == nil {
+	lock, ok := l.channels.Get(key)
+	if !ok {
 		return nil
 	}
 
-	lock := v.(*lockData)
+	lock.mu.Lock()
+	defer lock.mu.Unlock()
+
+	return lock.info()
+}
+
+// Active returns state of semaphores that are held or waited on by their keys.
+func (l *Limiter) Active() map[string]*LockInfo {
+	locks := make(map[string]*lockData)
 
-	return &LockInfo{
-		Capacity: cap(lock.ch),
-		Taken:    len(lock.ch),
-		Queued:   int(atomic.LoadInt32(&lock.queue)),
+	l.channels.Reduce(func(key string, lock *lockData, _ interface{}) interface{} {
+		locks[key] = lock
+		return nil
+	})
+
+	active := make(map[string]*LockInfo, len(locks))
+
+	for key, lock := range locks {
+		lock.mu.Lock()
+
+		if lock.taken > 0 || lock.queued > 0 {
+			active[key] = lock.info()
+		}
+
+		lock.mu.Unlock()
 	}
+
+	return active
 }
 
-// Unlock returns lock to semaphore pool.
+// Unlock returns the most recently acquired lock on key to semaphore pool. Limit is ignored.
+//
+// Deprecated: use Handle.Release instead.
 func (l *Limiter) Unlock(key string, limit int) {
-	key = fmt.Sprintf(lockTemplate, key, limit)
-
-	v := l.channels.Get(key)
-	if v == nil {
+	lock, ok := l.channels.Get(key)
+	if !ok {
 		return
 	}
 
-	<-v.(*lockData).ch
+	lock.mu.Lock()
+	e := lock.holders.Back()
+	lock.mu.Unlock()
+
+	if e != nil {
+		e.Value.(*Handle).Release()
+	}
 }
 
 // Shutdown stops everything.
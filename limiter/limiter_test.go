@@ -2,6 +2,7 @@ package limiter
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -59,15 +60,251 @@ func TestLimiter(t *testing.T) {
 			_, err = l.Lock(context.Background(), "key", 1)
 			So(err, ShouldBeNil)
 		})
+		Convey("Release is idempotent", func() {
+			h, err := l.Lock(context.Background(), "key", 2)
+			So(err, ShouldBeNil)
+			So(h.Capacity, ShouldEqual, 2)
+			So(h.Taken, ShouldEqual, 1)
+
+			h2, _ := l.Lock(context.Background(), "key", 2)
+			h.Release()
+			h.Release()
+			So(l.Info("key").Taken, ShouldEqual, 1)
+			h2.Release()
+			So(l.Info("key").Taken, ShouldEqual, 0)
+		})
+		Convey("Info reports age of the oldest holder", func() {
+			h, _ := l.Lock(context.Background(), "key", 2)
+			time.Sleep(20 * time.Millisecond)
+			l.Lock(context.Background(), "key", 2)
+
+			So(l.Info("key").OldestHolderAge, ShouldBeGreaterThanOrEqualTo, 20*time.Millisecond)
+			h.Release()
+			So(l.Info("key").OldestHolderAge, ShouldBeLessThan, 20*time.Millisecond)
+			l.Unlock("key", 2)
+			So(l.Info("key").OldestHolderAge, ShouldEqual, 0)
+		})
+		Convey("Lock grants waiters in FIFO order", func() {
+			l.cfg.Queue = 10
+			So(waitOrder(l, []Priority{0, 0, 0, 0, 0}), ShouldResemble, []int{0, 1, 2, 3, 4})
+		})
+		Convey("Lock shares capacity between priority classes by weights", func() {
+			l.cfg.Queue = 10
+			l.cfg.Weights = map[Priority]int{0: 3}
+			So(waitOrder(l, []Priority{1, 1, 1, 1, 0, 0, 0, 0}), ShouldResemble, []int{4, 0, 5, 6, 7, 1, 2, 3})
+		})
+		Convey("Lock removes canceled waiters from queue", func() {
+			l.cfg.Queue = 10
+			h, _ := l.Lock(context.Background(), "key", 1)
+			ctx, cancel := context.WithCancel(context.Background())
+			ch := make(chan error, 1)
+
+			go func() {
+				_, err := l.Lock(ctx, "key", 1)
+				ch <- err
+			}()
+
+			waitQueued(l, "key", 1)
+			cancel()
+			So(<-ch, ShouldEqual, context.Canceled)
+			So(l.Info("key").Queued, ShouldEqual, 0)
+
+			h.Release()
+			So(l.Info("key").Taken, ShouldEqual, 0)
+		})
+		Convey("raising limit admits queued waiters right away", func() {
+			l.cfg.Queue = 10
+			h, _ := l.Lock(context.Background(), "key", 1)
+			ch := make(chan *Handle, 1)
+
+			go func() {
+				h, _ := l.Lock(context.Background(), "key", 1)
+				ch <- h
+			}()
+
+			waitQueued(l, "key", 1)
+			l.SetLimit("key", 2)
+			h2 := <-ch
+			info := l.Info("key")
+			So(info.Capacity, ShouldEqual, 2)
+			So(info.Taken, ShouldEqual, 2)
+			So(info.Queued, ShouldEqual, 0)
+
+			h.Release()
+			h2.Release()
+		})
+		Convey("lowering limit drains held locks", func() {
+			h1, _ := l.Lock(context.Background(), "key", 2)
+			h2, _ := l.Lock(context.Background(), "key", 2)
+			l.SetLimit("key", 1)
+
+			info := l.Info("key")
+			So(info.Capacity, ShouldEqual, 1)
+			So(info.EffectiveCapacity, ShouldEqual, 2)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+
+			_, err := l.Lock(ctx, "key", 1)
+			So(errors.Is(err, context.DeadlineExceeded), ShouldBeTrue)
+
+			h1.Release()
+			So(l.Info("key").EffectiveCapacity, ShouldEqual, 1)
+
+			_, err = l.Lock(ctx, "key", 1)
+			So(errors.Is(err, context.DeadlineExceeded), ShouldBeTrue)
+
+			h2.Release()
+			_, err = l.Lock(context.Background(), "key", 1)
+			So(err, ShouldBeNil)
+		})
+		Convey("SetLimit ignores non existing keys", func() {
+			l.SetLimit("key", 2)
+			So(l.Info("key"), ShouldBeNil)
+		})
+		Convey("Lock acquires and releases multiple units", func() {
+			h, err := l.Lock(context.Background(), "key", 4, WithUnits(3))
+			So(err, ShouldBeNil)
+			So(h.Units(), ShouldEqual, 3)
+			So(h.Taken, ShouldEqual, 3)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+
+			_, err = l.Lock(ctx, "key", 4, WithUnits(2))
+			So(errors.Is(err, context.DeadlineExceeded), ShouldBeTrue)
+
+			h.Release()
+			h, err = l.Lock(context.Background(), "key", 4, WithUnits(2))
+			So(err, ShouldBeNil)
+			So(l.Info("key").Taken, ShouldEqual, 2)
+			h.Release()
+		})
+		Convey("large requests are not starved by small ones", func() {
+			l.cfg.Queue = 10
+			h, _ := l.Lock(context.Background(), "key", 4)
+			large := make(chan *Handle, 1)
+			small := make(chan *Handle, 1)
+
+			go func() {
+				h, _ := l.Lock(context.Background(), "key", 4, WithUnits(4))
+				large <- h
+			}()
+			waitQueued(l, "key", 1)
+
+			go func() {
+				h, _ := l.Lock(context.Background(), "key", 4)
+				small <- h
+			}()
+			waitQueued(l, "key", 2)
+
+			So(l.Info("key").Taken, ShouldEqual, 1)
+			h.Release()
+
+			h = <-large
+			So(l.Info("key").Taken, ShouldEqual, 4)
+			h.Release()
+
+			h = <-small
+			So(l.Info("key").Taken, ShouldEqual, 1)
+			h.Release()
+		})
+		Convey("Lock returns error when units exceed limit", func() {
+			_, err := l.Lock(context.Background(), "key", 2, WithUnits(3))
+			So(err, ShouldEqual, ErrUnitsExceedLimit)
+		})
+		Convey("lowering limit fails waiters that no longer fit", func() {
+			l.cfg.Queue = 10
+			h, _ := l.Lock(context.Background(), "key", 4, WithUnits(2))
+			ch := make(chan error, 1)
+
+			go func() {
+				_, err := l.Lock(context.Background(), "key", 4, WithUnits(3))
+				ch <- err
+			}()
+
+			waitQueued(l, "key", 1)
+			l.SetLimit("key", 2)
+			So(<-ch, ShouldEqual, ErrUnitsExceedLimit)
+			So(l.Info("key").Queued, ShouldEqual, 0)
+			h.Release()
+		})
 		Convey("Unlock silently quits for non existing keys", func() {
 			l.Unlock("key", 2)
 		})
 		Convey("createLock returns existing channel if one was created in the mean time", func() {
-			c1 := &lockData{ch: make(chan struct{}, 1)}
+			c1 := newLockData(1)
 			l.channels.Set("key", c1)
 			c2 := l.createLock("key", 2)
 			So(c1, ShouldEqual, c2)
 		})
 		l.Shutdown()
 	})
+
+	Convey("Given limiter with max hold", t, func() {
+		l := New(WithMaxHold(10*time.Millisecond), WithLogger(nil))
+
+		Convey("locks held for too long are force released", func() {
+			h, err := l.Lock(context.Background(), "key", 1)
+			So(err, ShouldBeNil)
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			h2, err := l.Lock(ctx, "key", 1)
+			So(err, ShouldBeNil)
+			h.Release()
+			So(l.Info("key").Taken, ShouldEqual, 1)
+			h2.Release()
+		})
+		Convey("released locks are not force released", func() {
+			h, _ := l.Lock(context.Background(), "key", 1)
+			h.Release()
+			h2, _ := l.Lock(context.Background(), "key", 1)
+			h2.Release()
+			So(h.timer.Stop(), ShouldBeFalse)
+		})
+		Convey("nil logger keeps default one", func() {
+			So(l.cfg.Logger, ShouldNotBeNil)
+		})
+
+		l.Shutdown()
+	})
+}
+
+// waitOrder queues Lock calls with given priorities one by one on a full semaphore and returns order in which they
+// were granted.
+func waitOrder(l *Limiter, prios []Priority) []int {
+	h, _ := l.Lock(context.Background(), "order", 1)
+	granted := make(chan int, len(prios))
+	handles := make([]*Handle, len(prios))
+
+	for i, p := range prios {
+		i, p := i, p
+
+		go func() {
+			handles[i], _ = l.Lock(context.Background(), "order", 1, WithPriority(p))
+			granted <- i
+		}()
+
+		waitQueued(l, "order", i+1)
+	}
+
+	h.Release()
+
+	order := make([]int, 0, len(prios))
+
+	for range prios {
+		i := <-granted
+		order = append(order, i)
+		handles[i].Release()
+	}
+
+	return order
+}
+
+func waitQueued(l *Limiter, key string, n int) {
+	for l.Info(key).Queued != n {
+		time.Sleep(time.Millisecond)
+	}
 }
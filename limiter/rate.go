@@ -0,0 +1,312 @@
+package limiter
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Syncano/pkg-go/v2/cache"
+)
+
+// Algorithm selects how RateLimiter counts events.
+type Algorithm int
+
+const (
+	// TokenBucket refills Burst sized bucket with Limit tokens per Period. Allows short bursts.
+	TokenBucket Algorithm = iota
+	// SlidingWindowLog keeps timestamps of events from last Period. Exact but uses memory proportional to Limit.
+	SlidingWindowLog
+	// SlidingWindowCounter approximates sliding window by weighting counts of current and previous fixed window.
+	SlidingWindowCounter
+)
+
+// Rate defines how many events are allowed per period.
+type Rate struct {
+	Limit  int
+	Period time.Duration
+	// Burst is a capacity of token bucket. Defaults to Limit.
+	Burst int
+}
+
+// PerSecond returns rate of n events per second.
+func PerSecond(n int) Rate {
+	return Rate{Limit: n, Period: time.Second}
+}
+
+// PerMinute returns rate of n events per minute.
+func PerMinute(n int) Rate {
+	return Rate{Limit: n, Period: time.Minute}
+}
+
+func (r Rate) burst() int {
+	if r.Burst > 0 {
+		return r.Burst
+	}
+
+	return r.Limit
+}
+
+// Result describes outcome of rate limit check.
+type Result struct {
+	Allowed bool
+	// Remaining is a number of events that would still be allowed right now.
+	Remaining int
+	// RetryAfter is a time after which rejected events would be allowed or, for reservations, a time to wait
+	// before acting. It is negative if events can never be allowed with given rate.
+	RetryAfter time.Duration
+}
+
+// rateState holds counters of a single key. Reserve makes allowed events take effect after returned delay.
+type rateState interface {
+	take(now time.Time, rate Rate, n int, reserve bool) *Result
+}
+
+// RateLimiter allows rate limiting of events per key. State of keys unused for TTL is expired
+// so TTL should be longer than period of used rates.
+type RateLimiter struct {
+	mu        sync.Mutex
+	algorithm Algorithm
+	states    *cache.LRU[string, *rateEntry]
+	now       func() time.Time
+
+	cfg Config
+}
+
+type rateEntry struct {
+	mu    sync.Mutex
+	state rateState
+}
+
+// NewRateLimiter initializes new rate limiter using given algorithm.
+func NewRateLimiter(algorithm Algorithm, opts ...Option) *RateLimiter {
+	cfg := DefaultConfig
+
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	return &RateLimiter{
+		algorithm: algorithm,
+		states:    cache.NewLRU[string, *rateEntry](true, cache.WithTTL(cfg.TTL)),
+		now:       time.Now,
+		cfg:       cfg,
+	}
+}
+
+func (l *RateLimiter) entry(key string) *rateEntry {
+	if e, ok := l.states.Get(key); ok {
+		return e
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e, ok := l.states.Get(key)
+	if !ok {
+		e = &rateEntry{state: l.newState()}
+		l.states.Set(key, e)
+	}
+
+	return e
+}
+
+func (l *RateLimiter) newState() rateState {
+	switch l.algorithm {
+	case SlidingWindowLog:
+		return &windowLog{}
+	case SlidingWindowCounter:
+		return &windowCounter{counts: make(map[int64]int)}
+	default:
+		return &tokenBucket{}
+	}
+}
+
+func (l *RateLimiter) take(key string, rate Rate, n int, reserve bool) *Result {
+	max := rate.Limit
+	if l.algorithm == TokenBucket {
+		max = rate.burst()
+	}
+
+	if n > max || rate.Limit <= 0 || rate.Period <= 0 {
+		return &Result{RetryAfter: -1}
+	}
+
+	e := l.entry(key)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.state.take(l.now(), rate, n, reserve)
+}
+
+// Allow reports whether an event at key may happen now with given rate and records it if so.
+func (l *RateLimiter) Allow(key string, rate Rate) *Result {
+	return l.AllowN(key, rate, 1)
+}
+
+// AllowN reports whether n events at key may happen now with given rate and records them if so.
+func (l *RateLimiter) AllowN(key string, rate Rate, n int) *Result {
+	return l.take(key, rate, n, false)
+}
+
+// Reserve records an event at key at the earliest time allowed by rate. Caller should wait RetryAfter before acting.
+// Result is not Allowed only if event can never be allowed with given rate.
+func (l *RateLimiter) Reserve(key string, rate Rate) *Result {
+	return l.ReserveN(key, rate, 1)
+}
+
+// ReserveN records n events at key at the earliest time allowed by rate. See Reserve.
+func (l *RateLimiter) ReserveN(key string, rate Rate, n int) *Result {
+	return l.take(key, rate, n, true)
+}
+
+// Shutdown stops everything.
+func (l *RateLimiter) Shutdown() {
+	// Stop cache janitor.
+	l.states.StopJanitor()
+}
+
+// tokenBucket allows events while there are tokens left. Reservations take tokens in advance.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func (b *tokenBucket) take(now time.Time, rate Rate, n int, reserve bool) *Result {
+	burst := float64(rate.burst())
+	// Computed in float as period may be shorter than limit in nanoseconds.
+	perToken := float64(rate.Period) / float64(rate.Limit)
+
+	if b.last.IsZero() {
+		b.tokens = burst
+	} else if now.After(b.last) {
+		b.tokens = math.Min(burst, b.tokens+float64(now.Sub(b.last))/perToken)
+	}
+
+	b.last = now
+
+	res := &Result{Allowed: true}
+
+	if missing := float64(n) - b.tokens; missing > 0 {
+		res.RetryAfter = time.Duration(math.Ceil(missing * perToken))
+		res.Allowed = reserve
+	}
+
+	if res.Allowed {
+		b.tokens -= float64(n)
+	}
+
+	res.Remaining = int(math.Max(0, b.tokens))
+
+	return res
+}
+
+// windowLog keeps sorted times of events from last period, including reserved ones from the future.
+type windowLog struct {
+	times []time.Time
+}
+
+func (w *windowLog) take(now time.Time, rate Rate, n int, reserve bool) *Result {
+	cutoff := now.Add(-rate.Period)
+
+	i := sort.Search(len(w.times), func(i int) bool {
+		return w.times[i].After(cutoff)
+	})
+	w.times = w.times[i:]
+
+	res := &Result{Allowed: true}
+	at := now
+
+	// Event is allowed once enough of the oldest events leave the window.
+	if over := len(w.times) + n - rate.Limit; over > 0 {
+		at = w.times[over-1].Add(rate.Period)
+		res.RetryAfter = at.Sub(now)
+		res.Allowed = reserve
+	}
+
+	if res.Allowed {
+		for j := 0; j < n; j++ {
+			k := sort.Search(len(w.times), func(i int) bool {
+				return w.times[i].After(at)
+			})
+			w.times = append(w.times, time.Time{})
+			copy(w.times[k+1:], w.times[k:])
+			w.times[k] = at
+		}
+	}
+
+	res.Remaining = rate.Limit - w.count(now)
+	if res.Remaining < 0 {
+		res.Remaining = 0
+	}
+
+	return res
+}
+
+// count returns number of events that happened until now.
+func (w *windowLog) count(now time.Time) int {
+	return sort.Search(len(w.times), func(i int) bool {
+		return w.times[i].After(now)
+	})
+}
+
+// windowCounter counts events in fixed windows and estimates sliding window count as count of current window
+// plus count of previous window weighted by part of it that is still within period.
+type windowCounter struct {
+	counts map[int64]int
+}
+
+func (w *windowCounter) estimate(window int64, frac float64) float64 {
+	return float64(w.counts[window-1])*(1-frac) + float64(w.counts[window])
+}
+
+func (w *windowCounter) take(now time.Time, rate Rate, n int, reserve bool) *Result {
+	period := int64(rate.Period)
+	cur := now.UnixNano() / period
+	frac := float64(now.UnixNano()%period) / float64(period)
+
+	for window := range w.counts {
+		if window < cur-1 {
+			delete(w.counts, window)
+		}
+	}
+
+	res := &Result{Allowed: true}
+	limit := float64(rate.Limit - n)
+
+	// Find the earliest window and its fraction when estimate allows n more events.
+	window, at := cur, frac
+
+	for {
+		if float64(w.counts[window]) <= limit {
+			prev := float64(w.counts[window-1])
+			if prev > 0 {
+				at = math.Max(at, 1-(limit-float64(w.counts[window]))/prev)
+			}
+
+			if at < 1 {
+				break
+			}
+		}
+
+		window++
+		at = 0
+	}
+
+	if window != cur || at > frac {
+		res.RetryAfter = time.Duration(float64(window-cur)*float64(period) + (at-frac)*float64(period))
+		res.Allowed = reserve
+	}
+
+	if res.Allowed {
+		w.counts[window] += n
+	}
+
+	res.Remaining = int(float64(rate.Limit) - w.estimate(cur, frac))
+	if res.Remaining < 0 {
+		res.Remaining = 0
+	}
+
+	return res
+}
@@ -0,0 +1,105 @@
+package limiter
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Unix(1000, 0)
+	clock := func() time.Time { return now }
+	rate := Rate{Limit: 2, Period: time.Second}
+
+	Convey("Given token bucket rate limiter", t, func() {
+		now = time.Unix(1000, 0)
+		l := NewRateLimiter(TokenBucket)
+		l.now = clock
+
+		Convey("allows burst and then refills tokens over time", func() {
+			So(l.Allow("key", rate), ShouldResemble, &Result{Allowed: true, Remaining: 1})
+			So(l.Allow("key", rate), ShouldResemble, &Result{Allowed: true, Remaining: 0})
+			So(l.Allow("key", rate), ShouldResemble, &Result{RetryAfter: 500 * time.Millisecond})
+			So(l.Allow("other", rate).Allowed, ShouldBeTrue)
+
+			now = now.Add(500 * time.Millisecond)
+			So(l.Allow("key", rate).Allowed, ShouldBeTrue)
+			So(l.Allow("key", rate).Allowed, ShouldBeFalse)
+		})
+		Convey("reserve takes tokens in advance", func() {
+			So(l.ReserveN("key", rate, 2).RetryAfter, ShouldEqual, 0)
+			So(l.Reserve("key", rate), ShouldResemble, &Result{Allowed: true, RetryAfter: 500 * time.Millisecond})
+			So(l.Reserve("key", rate).RetryAfter, ShouldEqual, time.Second)
+		})
+		Convey("refills tokens when period is shorter than limit in nanoseconds", func() {
+			fast := Rate{Limit: 3, Period: 2 * time.Nanosecond}
+			So(l.AllowN("key", fast, 3).Allowed, ShouldBeTrue)
+			So(l.Allow("key", fast), ShouldResemble, &Result{RetryAfter: time.Nanosecond})
+
+			now = now.Add(time.Nanosecond)
+			So(l.Allow("key", fast).Allowed, ShouldBeTrue)
+		})
+		Convey("burst overrides bucket capacity", func() {
+			So(l.AllowN("key", Rate{Limit: 1, Period: time.Second, Burst: 3}, 3).Allowed, ShouldBeTrue)
+		})
+		Convey("requests exceeding capacity are never allowed", func() {
+			So(l.ReserveN("key", rate, 3), ShouldResemble, &Result{RetryAfter: -1})
+			So(l.Allow("key", Rate{}), ShouldResemble, &Result{RetryAfter: -1})
+		})
+
+		l.Shutdown()
+	})
+
+	Convey("Given sliding window log rate limiter", t, func() {
+		now = time.Unix(1000, 0)
+		l := NewRateLimiter(SlidingWindowLog)
+		l.now = clock
+
+		Convey("allows events once the oldest ones leave the window", func() {
+			So(l.Allow("key", rate).Allowed, ShouldBeTrue)
+			now = now.Add(400 * time.Millisecond)
+			So(l.Allow("key", rate), ShouldResemble, &Result{Allowed: true, Remaining: 0})
+			So(l.Allow("key", rate), ShouldResemble, &Result{RetryAfter: 600 * time.Millisecond})
+
+			now = now.Add(600 * time.Millisecond)
+			So(l.Allow("key", rate), ShouldResemble, &Result{Allowed: true, Remaining: 0})
+		})
+		Convey("reservations occupy the window at reserved time", func() {
+			So(l.ReserveN("key", rate, 2).RetryAfter, ShouldEqual, 0)
+			So(l.Reserve("key", rate).RetryAfter, ShouldEqual, time.Second)
+			So(l.Reserve("key", rate).RetryAfter, ShouldEqual, time.Second)
+			So(l.Reserve("key", rate).RetryAfter, ShouldEqual, 2*time.Second)
+			So(l.Allow("key", rate).Allowed, ShouldBeFalse)
+		})
+		Convey("burst is ignored", func() {
+			So(l.AllowN("key", Rate{Limit: 1, Period: time.Second, Burst: 3}, 2).RetryAfter, ShouldEqual, -1)
+		})
+
+		l.Shutdown()
+	})
+
+	Convey("Given sliding window counter rate limiter", t, func() {
+		now = time.Unix(1000, 0)
+		l := NewRateLimiter(SlidingWindowCounter)
+		l.now = clock
+
+		Convey("weights previous window by its remaining part", func() {
+			So(l.AllowN("key", rate, 2).Allowed, ShouldBeTrue)
+			So(l.Allow("key", rate), ShouldResemble, &Result{RetryAfter: time.Second + 500*time.Millisecond})
+
+			now = now.Add(time.Second + 250*time.Millisecond)
+			So(l.Allow("key", rate), ShouldResemble, &Result{RetryAfter: 250 * time.Millisecond})
+
+			now = now.Add(250 * time.Millisecond)
+			So(l.Allow("key", rate), ShouldResemble, &Result{Allowed: true, Remaining: 0})
+		})
+		Convey("reserve records event in the window it is allowed in", func() {
+			So(l.ReserveN("key", rate, 2).RetryAfter, ShouldEqual, 0)
+			So(l.Reserve("key", rate).RetryAfter, ShouldEqual, time.Second+500*time.Millisecond)
+			So(l.Reserve("key", rate).RetryAfter, ShouldEqual, 2*time.Second)
+		})
+
+		l.Shutdown()
+	})
+}
@@ -0,0 +1,462 @@
+package limiter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+	"go.uber.org/zap"
+
+	"github.com/Syncano/pkg-go/v2/util"
+)
+
+// RedisLimiter allows semaphore rate limiting functionality shared by all processes using the same redis.
+// Each lock is a lease in a sorted set scored by its expiration time. Leases are renewed while process holds them
+// so lock of a crashed process is released once its lease ttl passes.
+// Queue bound is enforced per process.
+type RedisLimiter struct {
+	cli     redis.Cmdable
+	cfg     Config
+	metrics *metrics
+
+	mu     sync.Mutex
+	held   map[string][]*Handle
+	queued map[string]int
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// redisNow computes current time in milliseconds from redis server clock so that leases don't depend on clock skew
+// between processes.
+const redisNow = `
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+`
+
+// minLeaseTTL is the shortest lease ttl that can be renewed, as leases are kept in milliseconds and renewed
+// three times per ttl.
+const minLeaseTTL = 3 * time.Millisecond
+
+var errUnexpectedReply = errors.New("unexpected redis reply")
+
+var (
+	// acquireScript adds ARGV[5] leases to KEYS[1] if they fit in ARGV[1] valid leases.
+	// Lease members are prefixed with acquire time so that age of holders can be computed.
+	// Returns number of leases taken after acquiring followed by added lease members or 0 if limit is reached.
+	acquireScript = redis.NewScript(`redis.replicate_commands()` + redisNow + `
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now)
+redis.call('SET', KEYS[2], ARGV[1], 'PX', ARGV[4])
+
+local units = tonumber(ARGV[5])
+local taken = redis.call('ZCARD', KEYS[1])
+if taken + units > tonumber(ARGV[1]) then
+	return {0}
+end
+
+local res = {taken + units}
+for i = 1, units do
+	local member = string.format('%d:%s:%d', now, ARGV[2], i)
+	redis.call('ZADD', KEYS[1], now + tonumber(ARGV[3]), member)
+	res[i + 1] = member
+end
+
+redis.call('PEXPIRE', KEYS[1], ARGV[4])
+
+return res
+`)
+
+	// renewScript extends leases ARGV[3..] in KEYS[1] that still exist by ARGV[1] milliseconds.
+	renewScript = redis.NewScript(`redis.replicate_commands()` + redisNow + `
+for i = 3, #ARGV do
+	redis.call('ZADD', KEYS[1], 'XX', now + tonumber(ARGV[1]), ARGV[i])
+end
+
+redis.call('PEXPIRE', KEYS[1], ARGV[2])
+redis.call('PEXPIRE', KEYS[2], ARGV[2])
+`)
+
+	// infoScript returns number of valid leases in KEYS[1], limit stored in KEYS[2] and age of the oldest lease
+	// in milliseconds.
+	infoScript = redis.NewScript(redisNow + `
+local leases = redis.call('ZRANGEBYSCORE', KEYS[1], '(' .. now, '+inf')
+local limit = tonumber(redis.call('GET', KEYS[2]) or '0')
+local oldest = now
+
+for _, member in ipairs(leases) do
+	local acquired = tonumber(string.match(member, '^(%d+):'))
+	if acquired and acquired < oldest then
+		oldest = acquired
+	end
+end
+
+return {#leases, limit, now - oldest}
+`)
+)
+
+// NewRedis initializes new redis backed limiter and starts lease renewal process.
+// Non-positive lease ttl and poll interval fall back to defaults and lease ttl is at least minLeaseTTL.
+func NewRedis(cli redis.Cmdable, opts ...Option) *RedisLimiter {
+	cfg := DefaultConfig
+
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	cfg.Redis = cli
+
+	switch {
+	case cfg.LeaseTTL <= 0:
+		cfg.LeaseTTL = DefaultConfig.LeaseTTL
+	case cfg.LeaseTTL < minLeaseTTL:
+		cfg.LeaseTTL = minLeaseTTL
+	}
+
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = DefaultConfig.PollInterval
+	}
+
+	l := &RedisLimiter{
+		cli:     cli,
+		cfg:     cfg,
+		metrics: newMetrics(cfg.MetricsName),
+		held:    make(map[string][]*Handle),
+		queued:  make(map[string]int),
+		stop:    make(chan struct{}),
+	}
+
+	go l.renew()
+
+	return l
+}
+
+// withContext returns client bound to ctx so that its commands are canceled along with ctx.
+// Clients that cannot be bound are returned as is.
+func withContext(ctx context.Context, cli redis.Cmdable) redis.Cmdable {
+	switch c := cli.(type) {
+	case *redis.Client:
+		return c.WithContext(ctx)
+	case *redis.ClusterClient:
+		return c.WithContext(ctx)
+	case *redis.Ring:
+		return c.WithContext(ctx)
+	}
+
+	return cli
+}
+
+// ctxErr returns error of ctx if it is done or its deadline has passed, as redis commands may hit the deadline
+// before ctx is done.
+func ctxErr(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok && !time.Now().Before(deadline) {
+		return context.DeadlineExceeded
+	}
+
+	return nil
+}
+
+func (l *RedisLimiter) redisKeys(key string) []string {
+	key = fmt.Sprintf("%s:{%s}", l.cfg.KeyPrefix, key)
+	return []string{key + ":leases", key + ":limit"}
+}
+
+func (l *RedisLimiter) enqueue(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.queued[key] >= l.cfg.Queue {
+		return false
+	}
+
+	l.queued[key]++
+
+	return true
+}
+
+func (l *RedisLimiter) dequeue(key string) {
+	l.mu.Lock()
+
+	if l.queued[key]--; l.queued[key] <= 0 {
+		delete(l.queued, key)
+	}
+
+	l.mu.Unlock()
+}
+
+// Lock tries to get a lock on a semaphore on key with limit. It polls redis until lock is acquired or context is done.
+// Returned handle has to be released after use. Priority is ignored and waiters are not ordered as they poll
+// from different processes, so a large request of WithUnits may wait for long under constant load.
+func (l *RedisLimiter) Lock(ctx context.Context, key string, limit int, opts ...LockOption) (*Handle, error) {
+	start := time.Now()
+	h, err := l.lock(ctx, key, limit, opts)
+	l.metrics.recordLock(start, err)
+
+	return h, err
+}
+
+func (l *RedisLimiter) lock(ctx context.Context, key string, limit int, opts []LockOption) (*Handle, error) {
+	if limit <= 0 {
+		return nil, ErrMaxQueueSizeReached
+	}
+
+	lcfg := lockConfig{units: 1}
+
+	for _, opt := range opts {
+		opt(&lcfg)
+	}
+
+	if lcfg.units <= 0 {
+		lcfg.units = 1
+	}
+
+	if lcfg.units > limit {
+		return nil, ErrUnitsExceedLimit
+	}
+
+	if !l.enqueue(key) {
+		return nil, ErrMaxQueueSizeReached
+	}
+
+	defer l.dequeue(key)
+
+	cli := withContext(ctx, l.cli)
+	id := util.GenerateRandomString(16)
+	timer := time.NewTimer(0)
+
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		res, err := acquireScript.Run(cli, l.redisKeys(key),
+			limit, id, l.cfg.LeaseTTL.Milliseconds(), l.cfg.TTL.Milliseconds(), lcfg.units).Result()
+		if err != nil {
+			// Report commands interrupted by ctx the same way as if they were canceled while waiting.
+			if err := ctxErr(ctx); err != nil {
+				return nil, err
+			}
+
+			return nil, err
+		}
+
+		vals, _ := res.([]interface{})
+		if len(vals) == 0 {
+			return nil, errUnexpectedReply
+		}
+
+		if taken, _ := vals[0].(int64); taken > 0 {
+			members := make([]string, 0, len(vals)-1)
+			for _, v := range vals[1:] {
+				member, _ := v.(string)
+				members = append(members, member)
+			}
+
+			info := &LockInfo{Capacity: limit, EffectiveCapacity: limit, Taken: int(taken)}
+			if info.Taken > limit {
+				info.EffectiveCapacity = info.Taken
+			}
+
+			return l.hold(key, members, info), nil
+		}
+
+		timer.Reset(l.cfg.PollInterval)
+	}
+}
+
+// Info returns current state of a semaphore on key.
+// Queued only counts waiters of current process. Returns nil if state cannot be read from redis.
+func (l *RedisLimiter) Info(key string) *LockInfo {
+	res, err := infoScript.Run(l.cli, l.redisKeys(key)).Result()
+	if err != nil {
+		return nil
+	}
+
+	vals, ok := res.([]interface{})
+	if !ok || len(vals) != 3 {
+		return nil
+	}
+
+	taken, _ := vals[0].(int64)
+	limit, _ := vals[1].(int64)
+	age, _ := vals[2].(int64)
+
+	if limit == 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	queued := l.queued[key]
+	l.mu.Unlock()
+
+	info := &LockInfo{
+		Capacity:          int(limit),
+		EffectiveCapacity: int(limit),
+		Taken:             int(taken),
+		Queued:            queued,
+		OldestHolderAge:   time.Duration(age) * time.Millisecond,
+	}
+
+	if info.Taken > info.EffectiveCapacity {
+		info.EffectiveCapacity = info.Taken
+	}
+
+	return info
+}
+
+// Active returns state of semaphores that are held in redis or waited on by current process by their keys.
+// Returns nil if keys cannot be listed from redis.
+func (l *RedisLimiter) Active() map[string]*LockInfo {
+	prefix := l.cfg.KeyPrefix + ":{"
+	keys := make(map[string]struct{})
+
+	iter := l.cli.Scan(0, prefix+"*}:leases", 100).Iterator()
+	for iter.Next() {
+		keys[strings.TrimSuffix(strings.TrimPrefix(iter.Val(), prefix), "}:leases")] = struct{}{}
+	}
+
+	if iter.Err() != nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	for key := range l.queued {
+		keys[key] = struct{}{}
+	}
+	l.mu.Unlock()
+
+	active := make(map[string]*LockInfo, len(keys))
+
+	for key := range keys {
+		if info := l.Info(key); info != nil && (info.Taken > 0 || info.Queued > 0) {
+			active[key] = info
+		}
+	}
+
+	return active
+}
+
+// SetLimit changes capacity of an existing semaphore on key. Waiters notice raised capacity on their next poll.
+// When it is lowered, current holders keep their leases and new ones are granted only after enough of them expire
+// or are released.
+func (l *RedisLimiter) SetLimit(key string, limit int) {
+	if limit <= 0 {
+		return
+	}
+
+	l.cli.SetXX(l.redisKeys(key)[1], limit, l.cfg.TTL)
+}
+
+// hold registers lease of current process so that it is renewed until released.
+// If lease cannot be removed from redis on release, it is released after lease ttl.
+func (l *RedisLimiter) hold(key string, members []string, info *LockInfo) *Handle {
+	var h *Handle
+
+	h = newHandle(key, info, func() {
+		l.mu.Lock()
+
+		handles := l.held[key]
+		for i, cur := range handles {
+			if cur == h {
+				handles = append(handles[:i:i], handles[i+1:]...)
+				break
+			}
+		}
+
+		if len(handles) == 0 {
+			delete(l.held, key)
+		} else {
+			l.held[key] = handles
+		}
+
+		l.mu.Unlock()
+
+		args := make([]interface{}, len(members))
+		for i, m := range members {
+			args[i] = m
+		}
+
+		l.cli.ZRem(l.redisKeys(key)[0], args...)
+	})
+	h.ids = members
+	h.units = len(members)
+
+	l.mu.Lock()
+	l.held[key] = append(l.held[key], h)
+	l.mu.Unlock()
+
+	h.watch(l.cfg.MaxHold, l.cfg.Logger)
+
+	return h
+}
+
+// Unlock returns the most recently acquired lock held by current process to semaphore pool. Limit is ignored.
+//
+// Deprecated: use Handle.Release instead.
+func (l *RedisLimiter) Unlock(key string, limit int) {
+	l.mu.Lock()
+
+	var h *Handle
+	if handles := l.held[key]; len(handles) > 0 {
+		h = handles[len(handles)-1]
+	}
+
+	l.mu.Unlock()
+
+	if h != nil {
+		h.Release()
+	}
+}
+
+// renew periodically extends leases held by current process.
+func (l *RedisLimiter) renew() {
+	ticker := time.NewTicker(l.cfg.LeaseTTL / 3)
+
+	for {
+		select {
+		case <-ticker.C:
+			l.mu.Lock()
+			held := make(map[string][]interface{}, len(l.held))
+
+			for key, handles := range l.held {
+				args := []interface{}{l.cfg.LeaseTTL.Milliseconds(), l.cfg.TTL.Milliseconds()}
+				for _, h := range handles {
+					for _, id := range h.ids {
+						args = append(args, id)
+					}
+				}
+
+				held[key] = args
+			}
+
+			l.mu.Unlock()
+
+			for key, args := range held {
+				if err := renewScript.Run(l.cli, l.redisKeys(key), args...).Err(); err != nil && err != redis.Nil {
+					l.cfg.Logger.Warn("Lease renewal failed", zap.String("key", key), zap.Error(err))
+				}
+			}
+		case <-l.stop:
+			ticker.Stop()
+			return
+		}
+	}
+}
+
+// Shutdown stops lease renewal. Locks that are still held are released after lease ttl.
+func (l *RedisLimiter) Shutdown() {
+	l.stopOnce.Do(func() {
+		close(l.stop)
+	})
+}
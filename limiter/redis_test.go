@@ -0,0 +1,281 @@
+package limiter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v7"
+	. "github.com/smartystreets/goconvey/convey"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type ctxKey struct{}
+
+// ctxHook records values stored under ctxKey in contexts of processed commands.
+type ctxHook struct {
+	values []interface{}
+}
+
+func (h *ctxHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	h.values = append(h.values, ctx.Value(ctxKey{}))
+	return ctx, nil
+}
+
+func (h *ctxHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	return nil
+}
+
+func (h *ctxHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	return ctx, nil
+}
+
+func (h *ctxHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	return nil
+}
+
+func TestRedisLimiter(t *testing.T) {
+	Convey("Given redis client", t, func() {
+		// Nothing listens there so all commands fail right away.
+		cli := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1})
+
+		Convey("NewLocker creates redis limiter when redis is configured", func() {
+			l := NewLocker(WithRedis(cli))
+			So(l, ShouldHaveSameTypeAs, &RedisLimiter{})
+			l.Shutdown()
+
+			l = NewLocker()
+			So(l, ShouldHaveSameTypeAs, &Limiter{})
+			l.Shutdown()
+		})
+		Convey("NewRedis fixes lease ttl and poll interval that cannot be used", func() {
+			l := NewRedis(cli, WithLease(0, -1))
+			So(l.cfg.LeaseTTL, ShouldEqual, DefaultConfig.LeaseTTL)
+			So(l.cfg.PollInterval, ShouldEqual, DefaultConfig.PollInterval)
+			l.Shutdown()
+
+			l = NewRedis(cli, WithLease(1, time.Millisecond))
+			So(l.cfg.LeaseTTL, ShouldEqual, minLeaseTTL)
+			So(l.cfg.PollInterval, ShouldEqual, time.Millisecond)
+			l.Shutdown()
+		})
+		Convey("Lock passes its context to redis", func() {
+			hook := &ctxHook{}
+			cli.AddHook(hook)
+
+			l := NewRedis(cli)
+			ctx := context.WithValue(context.Background(), ctxKey{}, "lock")
+			_, err := l.Lock(ctx, "key", 1)
+			So(err, ShouldNotBeNil)
+			So(hook.values, ShouldContain, "lock")
+			l.Shutdown()
+		})
+		Convey("Given redis limiter", func() {
+			l := NewRedis(cli, WithQueue(0))
+
+			Convey("Lock returns error when limit is <= 0", func() {
+				_, err := l.Lock(context.Background(), "key", 0)
+				So(err, ShouldEqual, ErrMaxQueueSizeReached)
+			})
+			Convey("Lock returns error when units exceed limit", func() {
+				_, err := l.Lock(context.Background(), "key", 1, WithUnits(2))
+				So(err, ShouldEqual, ErrUnitsExceedLimit)
+			})
+			Convey("Lock returns error when queue is full", func() {
+				_, err := l.Lock(context.Background(), "key", 1)
+				So(err, ShouldEqual, ErrMaxQueueSizeReached)
+			})
+			Convey("Lock returns redis error", func() {
+				l.cfg.Queue = 1
+				_, err := l.Lock(context.Background(), "key", 1)
+				So(err, ShouldNotBeNil)
+				So(l.queued, ShouldBeEmpty)
+			})
+			Convey("Info returns nil on redis error", func() {
+				So(l.Info("key"), ShouldBeNil)
+			})
+			Convey("Active returns nil on redis error", func() {
+				So(l.Active(), ShouldBeNil)
+			})
+			Convey("Unlock silently quits for keys that are not held", func() {
+				l.Unlock("key", 1)
+			})
+			Convey("redis keys of a lock share hash slot", func() {
+				So(l.redisKeys("key"), ShouldResemble, []string{"limiter:{key}:leases", "limiter:{key}:limit"})
+			})
+
+			l.Shutdown()
+		})
+
+		cli.Close()
+	})
+}
+
+func TestRedisLimiterLeases(t *testing.T) {
+	Convey("Given redis limiter backed by redis", t, func() {
+		mr, err := miniredis.Run()
+		So(err, ShouldBeNil)
+
+		now := time.Now()
+		mr.SetTime(now)
+
+		cli := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+		l := NewRedis(cli, WithLease(time.Minute, time.Millisecond))
+		ctx := context.Background()
+
+		Convey("Lock acquires leases up to limit", func() {
+			h1, err := l.Lock(ctx, "key", 2)
+			So(err, ShouldBeNil)
+			So(h1.Taken, ShouldEqual, 1)
+
+			h2, err := l.Lock(ctx, "key", 2)
+			So(err, ShouldBeNil)
+			So(h2.Taken, ShouldEqual, 2)
+
+			tctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+			_, err = l.Lock(tctx, "key", 2)
+			cancel()
+			So(errors.Is(err, context.DeadlineExceeded), ShouldBeTrue)
+
+			h1.Release()
+
+			h3, err := l.Lock(ctx, "key", 2)
+			So(err, ShouldBeNil)
+			So(h3.Taken, ShouldEqual, 2)
+		})
+		Convey("Lock acquires multiple units at once", func() {
+			h, err := l.Lock(ctx, "key", 3, WithUnits(2))
+			So(err, ShouldBeNil)
+			So(h.Taken, ShouldEqual, 2)
+
+			members, _ := mr.ZMembers("limiter:{key}:leases")
+			So(members, ShouldHaveLength, 2)
+
+			h.Release()
+
+			So(mr.Exists("limiter:{key}:leases"), ShouldBeFalse)
+		})
+		Convey("lease that is not renewed expires", func() {
+			_, err := l.Lock(ctx, "key", 1)
+			So(err, ShouldBeNil)
+
+			mr.SetTime(now.Add(time.Minute + time.Second))
+
+			h, err := l.Lock(ctx, "key", 1)
+			So(err, ShouldBeNil)
+			So(h.Taken, ShouldEqual, 1)
+		})
+		Convey("renewal extends held leases", func() {
+			h, err := l.Lock(ctx, "key", 1)
+			So(err, ShouldBeNil)
+
+			mr.SetTime(now.Add(30 * time.Second))
+			_, err = renewScript.Run(cli, l.redisKeys("key"), time.Minute.Milliseconds(), time.Hour.Milliseconds(),
+				h.ids[0]).Result()
+			So(err, ShouldEqual, redis.Nil)
+
+			mr.SetTime(now.Add(time.Minute + time.Second))
+			So(l.Info("key").Taken, ShouldEqual, 1)
+			So(mr.TTL("limiter:{key}:limit"), ShouldEqual, time.Hour)
+
+			// Released leases are not brought back.
+			h.Release()
+			_, err = renewScript.Run(cli, l.redisKeys("key"), time.Minute.Milliseconds(), time.Hour.Milliseconds(),
+				h.ids[0]).Result()
+			So(err, ShouldEqual, redis.Nil)
+			So(l.Info("key").Taken, ShouldEqual, 0)
+		})
+		Convey("Info returns state of semaphore", func() {
+			So(l.Info("key"), ShouldBeNil)
+
+			_, err := l.Lock(ctx, "key", 2)
+			So(err, ShouldBeNil)
+
+			mr.SetTime(now.Add(5 * time.Second))
+
+			info := l.Info("key")
+			So(info.Capacity, ShouldEqual, 2)
+			So(info.EffectiveCapacity, ShouldEqual, 2)
+			So(info.Taken, ShouldEqual, 1)
+			So(info.OldestHolderAge, ShouldEqual, 5*time.Second)
+		})
+		Convey("SetLimit changes limit of existing semaphore only", func() {
+			_, err := l.Lock(ctx, "key", 1)
+			So(err, ShouldBeNil)
+
+			l.SetLimit("key", 3)
+			So(l.Info("key").Capacity, ShouldEqual, 3)
+
+			l.SetLimit("other", 3)
+			So(mr.Exists("limiter:{other}:limit"), ShouldBeFalse)
+
+			l.SetLimit("key", 0)
+			So(l.Info("key").Capacity, ShouldEqual, 3)
+		})
+		Convey("Info reports holders above lowered limit", func() {
+			for i := 0; i < 2; i++ {
+				_, err := l.Lock(ctx, "key", 2)
+				So(err, ShouldBeNil)
+			}
+
+			l.SetLimit("key", 1)
+
+			info := l.Info("key")
+			So(info.Capacity, ShouldEqual, 1)
+			So(info.EffectiveCapacity, ShouldEqual, 2)
+		})
+		Convey("Active returns held semaphores", func() {
+			h, err := l.Lock(ctx, "key1", 1)
+			So(err, ShouldBeNil)
+			_, err = l.Lock(ctx, "key2", 2)
+			So(err, ShouldBeNil)
+
+			active := l.Active()
+			So(active, ShouldHaveLength, 2)
+			So(active["key1"].Taken, ShouldEqual, 1)
+			So(active["key2"].Capacity, ShouldEqual, 2)
+
+			h.Release()
+			So(l.Active(), ShouldHaveLength, 1)
+		})
+
+		l.Shutdown()
+		mr.Close()
+	})
+}
+
+func TestRedisLimiterRenew(t *testing.T) {
+	Convey("Given redis limiter with short lease", t, func() {
+		mr, err := miniredis.Run()
+		So(err, ShouldBeNil)
+
+		core, logs := observer.New(zap.WarnLevel)
+		cli := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+		l := NewRedis(cli, WithLease(150*time.Millisecond, time.Millisecond), WithLogger(zap.New(core)))
+
+		_, err = l.Lock(context.Background(), "key", 1)
+		So(err, ShouldBeNil)
+
+		Convey("held lease is renewed until shutdown", func() {
+			time.Sleep(400 * time.Millisecond)
+			So(l.Info("key").Taken, ShouldEqual, 1)
+			So(logs.Len(), ShouldEqual, 0)
+
+			l.Shutdown()
+			time.Sleep(300 * time.Millisecond)
+			So(l.Info("key").Taken, ShouldEqual, 0)
+		})
+		Convey("failed renewal is logged", func() {
+			mr.Close()
+			time.Sleep(150 * time.Millisecond)
+
+			So(logs.FilterMessage("Lease renewal failed").Len(), ShouldBeGreaterThan, 0)
+		})
+
+		l.Shutdown()
+		mr.Close()
+	})
+}
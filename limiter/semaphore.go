@@ -0,0 +1,233 @@
+package limiter
+
+import (
+	"container/list"
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// waiter is a Lock call queued on a semaphore. Handle or error is set once it is done.
+type waiter struct {
+	units  int
+	ready  chan struct{}
+	handle *Handle
+	err    error
+	class  *waitQueue
+	elem   *list.Element
+}
+
+// waitQueue holds FIFO queue of waiters of a single priority class. Pass is a virtual time of a class advanced
+// by units/weight on each grant so that classes are served proportionally to their weights.
+type waitQueue struct {
+	priority Priority
+	weight   int
+	pass     float64
+	waiters  *list.List
+}
+
+type lockData struct {
+	mu       sync.Mutex
+	capacity int
+	taken    int
+	queued   int
+
+	classes []*waitQueue // sorted by priority.
+	holders *list.List   // of *Handle in order of acquiring.
+}
+
+func newLockData(limit int) *lockData {
+	return &lockData{
+		capacity: limit,
+		holders:  list.New(),
+	}
+}
+
+// acquire grants lock right away if there is capacity and nobody is waiting, otherwise waits in a queue.
+// Waiters are never skipped so that the one at the front eventually gets its units even if it needs many of them.
+func (l *Limiter) acquire(ctx context.Context, lock *lockData, key string, limit int, lcfg *lockConfig) (*Handle, error) {
+	lock.mu.Lock()
+
+	l.setCapacity(lock, key, limit)
+
+	if lcfg.units > lock.capacity {
+		lock.mu.Unlock()
+		return nil, ErrUnitsExceedLimit
+	}
+
+	if lock.queued >= l.cfg.Queue {
+		lock.mu.Unlock()
+		return nil, ErrMaxQueueSizeReached
+	}
+
+	if lock.queued == 0 && lock.taken+lcfg.units <= lock.capacity {
+		h := l.grant(lock, key, lcfg.units)
+		lock.mu.Unlock()
+
+		return h, nil
+	}
+
+	w := lock.enqueue(lcfg.priority, l.cfg.Weights[lcfg.priority], lcfg.units)
+	lock.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return w.handle, w.err
+	case <-ctx.Done():
+	}
+
+	lock.mu.Lock()
+
+	select {
+	case <-w.ready:
+		lock.mu.Unlock()
+
+		if w.handle != nil {
+			w.handle.Release()
+		}
+
+		return nil, ctx.Err()
+	default:
+	}
+
+	lock.remove(w)
+	l.dispatch(lock, key)
+	lock.mu.Unlock()
+
+	return nil, ctx.Err()
+}
+
+// grant takes units and registers a handle returning them. Requires lock.mu to be held.
+func (l *Limiter) grant(lock *lockData, key string, units int) *Handle {
+	var e *list.Element
+
+	lock.taken += units
+
+	h := newHandle(key, lock.info(), func() {
+		lock.mu.Lock()
+		lock.holders.Remove(e)
+		lock.taken -= units
+		l.dispatch(lock, key)
+		lock.mu.Unlock()
+	})
+	h.units = units
+	e = lock.holders.PushBack(h)
+
+	h.watch(l.cfg.MaxHold, l.cfg.Logger)
+
+	return h
+}
+
+// setCapacity changes capacity in place and lets waiters through if it was raised.
+// Waiters that need more units than new capacity are failed. Requires lock.mu to be held.
+func (l *Limiter) setCapacity(lock *lockData, key string, limit int) {
+	if lock.capacity == limit {
+		return
+	}
+
+	lock.capacity = limit
+	l.dispatch(lock, key)
+}
+
+// dispatch grants free units to waiters in order until the next one doesn't fit. Requires lock.mu to be held.
+func (l *Limiter) dispatch(lock *lockData, key string) {
+	for {
+		w := lock.next()
+		if w == nil {
+			return
+		}
+
+		switch {
+		case w.units > lock.capacity:
+			w.err = ErrUnitsExceedLimit
+		case lock.taken+w.units <= lock.capacity:
+			w.class.pass += float64(w.units) / float64(w.class.weight)
+			w.handle = l.grant(lock, key, w.units)
+		default:
+			return
+		}
+
+		lock.remove(w)
+		close(w.ready)
+	}
+}
+
+func (lock *lockData) enqueue(prio Priority, weight, units int) *waiter {
+	i := sort.Search(len(lock.classes), func(i int) bool {
+		return lock.classes[i].priority >= prio
+	})
+
+	if i == len(lock.classes) || lock.classes[i].priority != prio {
+		if weight <= 0 {
+			weight = 1
+		}
+
+		lock.classes = append(lock.classes, nil)
+		copy(lock.classes[i+1:], lock.classes[i:])
+		lock.classes[i] = &waitQueue{priority: prio, weight: weight, waiters: list.New()}
+	}
+
+	class := lock.classes[i]
+
+	// Class that was idle doesn't get credit for the time it was not waiting.
+	if class.waiters.Len() == 0 {
+		if head := lock.nextClass(); head != nil && head.pass > class.pass {
+			class.pass = head.pass
+		}
+	}
+
+	w := &waiter{units: units, ready: make(chan struct{}), class: class}
+	w.elem = class.waiters.PushBack(w)
+	lock.queued++
+
+	return w
+}
+
+func (lock *lockData) remove(w *waiter) {
+	w.class.waiters.Remove(w.elem)
+	lock.queued--
+}
+
+// nextClass returns non empty class with the lowest pass, ties are resolved by priority.
+func (lock *lockData) nextClass() *waitQueue {
+	var next *waitQueue
+
+	for _, class := range lock.classes {
+		if class.waiters.Len() > 0 && (next == nil || class.pass < next.pass) {
+			next = class
+		}
+	}
+
+	return next
+}
+
+// next returns waiter that should be granted a lock first.
+func (lock *lockData) next() *waiter {
+	if class := lock.nextClass(); class != nil {
+		return class.waiters.Front().Value.(*waiter)
+	}
+
+	return nil
+}
+
+// info returns current state of semaphore. Requires lock.mu to be held.
+func (lock *lockData) info() *LockInfo {
+	info := &LockInfo{
+		Capacity:          lock.capacity,
+		EffectiveCapacity: lock.capacity,
+		Taken:             lock.taken,
+		Queued:            lock.queued,
+	}
+
+	// Until semaphore is drained after capacity was lowered, more locks are held than configured.
+	if lock.taken > info.EffectiveCapacity {
+		info.EffectiveCapacity = lock.taken
+	}
+
+	if e := lock.holders.Front(); e != nil {
+		info.OldestHolderAge = time.Since(e.Value.(*Handle).acquired)
+	}
+
+	return info
+}
@@ -0,0 +1,76 @@
+package limiter
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	"github.com/Syncano/pkg-go/v2/util"
+)
+
+var (
+	// KeyLimiterName is a tag key holding limiter name set through WithMetrics.
+	KeyLimiterName = tag.MustNewKey("limiter")
+
+	MeasureWaitTime = stats.Float64("limiter/wait_time", "Time spent waiting for a lock",
+		stats.UnitMilliseconds)
+	MeasureRejections = stats.Int64("limiter/rejections", "Number of locks rejected due to full queue",
+		stats.UnitDimensionless)
+	MeasureCancellations = stats.Int64("limiter/cancellations", "Number of locks abandoned due to context being done",
+		stats.UnitDimensionless)
+
+	WaitTimeView = &view.View{
+		Measure:     MeasureWaitTime,
+		Aggregation: view.Distribution(0, 1, 5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000),
+		TagKeys:     []tag.Key{KeyLimiterName},
+	}
+	RejectionsView = &view.View{
+		Measure:     MeasureRejections,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{KeyLimiterName},
+	}
+	CancellationsView = &view.View{
+		Measure:     MeasureCancellations,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{KeyLimiterName},
+	}
+
+	// DefaultViews are the views registered by limiters created with WithMetrics.
+	DefaultViews = []*view.View{WaitTimeView, RejectionsView, CancellationsView}
+
+	views util.ViewsRegistry
+)
+
+type metrics struct {
+	ctx context.Context
+}
+
+func newMetrics(name string) *metrics {
+	if name == "" {
+		return nil
+	}
+
+	views.Register(DefaultViews...)
+
+	return &metrics{ctx: util.TagContext(KeyLimiterName, name)}
+}
+
+// recordLock records outcome of Lock call that started at given time.
+func (m *metrics) recordLock(start time.Time, err error) {
+	if m == nil {
+		return
+	}
+
+	switch {
+	case err == nil:
+		stats.Record(m.ctx, MeasureWaitTime.M(float64(time.Since(start))/float64(time.Millisecond)))
+	case errors.Is(err, ErrMaxQueueSizeReached):
+		stats.Record(m.ctx, MeasureRejections.M(1))
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		stats.Record(m.ctx, MeasureCancellations.M(1))
+	}
+}
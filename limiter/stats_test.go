@@ -0,0 +1,105 @@
+package limiter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	. "github.com/smartystreets/goconvey/convey"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+// retrieveRows returns rows of view tagged with given limiter name as views are shared by all limiters in process.
+func retrieveRows(viewName, name string) []*view.Row {
+	rows, err := view.RetrieveData(viewName)
+	So(err, ShouldBeNil)
+
+	var ret []*view.Row
+
+	for _, r := range rows {
+		for _, t := range r.Tags {
+			if t == (tag.Tag{Key: KeyLimiterName, Value: name}) {
+				ret = append(ret, r)
+			}
+		}
+	}
+
+	return ret
+}
+
+func TestStats(t *testing.T) {
+	Convey("Given limiter with metrics", t, func() {
+		name := fmt.Sprintf("stats_test_%d", time.Now().UnixNano())
+		l := New(WithQueue(1), WithMetrics(name))
+
+		Convey("wait time, rejections and cancellations are recorded as OpenCensus views", func() {
+			h, _ := l.Lock(context.Background(), "key", 1)
+			ch := make(chan *Handle, 1)
+
+			go func() {
+				h, _ := l.Lock(context.Background(), "key", 1)
+				ch <- h
+			}()
+
+			waitQueued(l, "key", 1)
+
+			_, err := l.Lock(context.Background(), "key", 1)
+			So(err, ShouldEqual, ErrMaxQueueSizeReached)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			_, err = l.Lock(ctx, "other", 1, WithUnits(1))
+			So(err, ShouldBeNil)
+			_, err = l.Lock(ctx, "other", 1)
+			So(err, ShouldEqual, context.Canceled)
+
+			h.Release()
+			(<-ch).Release()
+
+			rows := retrieveRows(WaitTimeView.Name, name)
+			So(rows, ShouldHaveLength, 1)
+			So(rows[0].Data.(*view.DistributionData).Count, ShouldEqual, 3)
+
+			rows = retrieveRows(RejectionsView.Name, name)
+			So(rows, ShouldHaveLength, 1)
+			So(rows[0].Data.(*view.CountData).Value, ShouldEqual, 1)
+
+			rows = retrieveRows(CancellationsView.Name, name)
+			So(rows, ShouldHaveLength, 1)
+			So(rows[0].Data.(*view.CountData).Value, ShouldEqual, 1)
+		})
+
+		l.Shutdown()
+	})
+
+	Convey("Given limiter", t, func() {
+		l := New()
+		h, _ := l.Lock(context.Background(), "key", 2)
+		l.Lock(context.Background(), "idle", 1)
+		l.Unlock("idle", 1)
+
+		Convey("Active lists held semaphores", func() {
+			active := l.Active()
+			So(active, ShouldHaveLength, 1)
+			So(active["key"].Taken, ShouldEqual, 1)
+			So(active["key"].Capacity, ShouldEqual, 2)
+		})
+		Convey("Handler dumps active semaphores as JSON", func() {
+			e := echo.New()
+			rec := httptest.NewRecorder()
+			c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+
+			So(Handler(l)(c), ShouldBeNil)
+			So(rec.Code, ShouldEqual, http.StatusOK)
+			So(rec.Body.String(), ShouldStartWith, `{"key":{"capacity":2,"effective_capacity":2,"taken":1,"queued":0,`)
+		})
+
+		h.Release()
+		l.Shutdown()
+	})
+}
@@ -3,6 +3,7 @@ package rediscache
 import (
 	"errors"
 	"reflect"
+	"sync"
 	"time"
 
 	"github.com/go-pg/pg/v9/orm"
@@ -10,6 +11,7 @@ import (
 	"github.com/go-redis/redis/v7"
 	"github.com/vmihailenco/msgpack/v4"
 
+	lcache "github.com/Syncano/pkg-go/v2/cache"
 	"github.com/Syncano/pkg-go/v2/database"
 	"github.com/Syncano/pkg-go/v2/util"
 )
@@ -19,13 +21,24 @@ const (
 )
 
 var (
-	ErrNil = errors.New("compute returned nil object and storeNil is false")
+	ErrNil                = errors.New("compute returned nil object and storeNil is false")
+	ErrLocalCacheDisabled = errors.New("local cache is not enabled")
 )
 
 type Cache struct {
 	codec *cache.Codec
 	db    *database.DB
 	cfg   Config
+
+	local     *lcache.LRUCache
+	localKeys *lcache.LRUSetCache
+
+	muPubSub      sync.RWMutex
+	pubsub        pubsuber
+	channel       string
+	invalidations chan string
+	stop          chan struct{}
+	stopped       chan struct{}
 }
 
 type Config struct {
@@ -36,6 +49,8 @@ type Config struct {
 	CacheVersion      int
 	ServiceKey        string
 	StoreNil          bool
+	LocalCache        bool
+	LocalCacheOptions []lcache.Option
 }
 
 var DefaultConfig = Config{
@@ -75,6 +90,15 @@ func WithStoreNil(storeNil bool) Option {
 	}
 }
 
+// WithLocalCache makes cache use in-memory LRUCache configured with opts as L1 instead of go-redis/cache local cache.
+// L1 ttl defaults to local cache timeout. See SubscribeInvalidations for invalidating L1 across instances.
+func WithLocalCache(opts ...lcache.Option) Option {
+	return func(config *Config) {
+		config.LocalCache = true
+		config.LocalCacheOptions = opts
+	}
+}
+
 func WithModelPartition(f func(db orm.DB, tableName string) string) Option {
 	return func(config *Config) {
 		config.ModelPartition = f
@@ -101,13 +125,19 @@ func New(r rediser, db *database.DB, opts ...Option) *Cache {
 		Marshal:   msgpack.Marshal,
 		Unmarshal: msgpack.Unmarshal,
 	}
-	codec.UseLocalCache(50000, cfg.LocalCacheTimeout)
-
-	return &Cache{
+	c := &Cache{
 		codec: codec,
 		db:    db,
 		cfg:   cfg,
 	}
+
+	if cfg.LocalCache {
+		c.initLocalCache(cfg.LocalCacheOptions)
+	} else {
+		codec.UseLocalCache(50000, cfg.LocalCacheTimeout)
+	}
+
+	return c
 }
 
 // Codec returns cache client.
@@ -138,7 +168,19 @@ func (c *Cache) VersionedCache(cacheKey, lookup string, val interface{},
 		err     error
 	)
 
-	// Get object and check version. First local and fallback to global cache.
+	// Get object and check version. First local and fallback to global cache. L1 entry is checked against version
+	// as well as its invalidation could have been missed or raced with storing it.
+	if c.getLocal(cacheKey, item) {
+		version, err = c.codec.Redis.Get(versionKeyFunc()).Result()
+		if err != nil && err != redis.Nil {
+			return err
+		}
+
+		if item.validate(version, validate) {
+			return nil
+		}
+	}
+
 	if c.codec.Get(cacheKey, item) == nil {
 		if version == "" {
 			version, err = c.codec.Redis.Get(versionKeyFunc()).Result()
@@ -148,6 +190,7 @@ func (c *Cache) VersionedCache(cacheKey, lookup string, val interface{},
 		}
 
 		if item.validate(version, validate) {
+			c.setLocal(cacheKey, versionKeyFunc(), item)
 			return nil
 		}
 	}
@@ -187,17 +230,31 @@ func (c *Cache) VersionedCache(cacheKey, lookup string, val interface{},
 	item.Version = version
 
 	// Set cache values.
-	return c.codec.Set(&cache.Item{
+	if err := c.codec.Set(&cache.Item{
 		Key:        cacheKey,
 		Object:     item,
 		Expiration: expiration,
-	})
+	}); err != nil {
+		return err
+	}
+
+	c.setLocal(cacheKey, versionKeyFunc(), item)
+
+	return nil
 }
 
+// InvalidateVersion changes version stored at versionKey which invalidates all values cached with it.
+// L1 entries are invalidated locally and on all instances subscribed through SubscribeInvalidations.
 func (c *Cache) InvalidateVersion(versionKey string, expiration time.Duration) error {
-	return c.codec.Redis.Set(
+	if err := c.codec.Redis.Set(
 		versionKey,
 		util.GenerateRandomString(4),
 		expiration+versionGraceDuration, // Add grace period to avoid race condition.
-	).Err()
+	).Err(); err != nil {
+		return err
+	}
+
+	c.invalidateLocal(versionKey)
+
+	return c.publishInvalidation(versionKey)
 }
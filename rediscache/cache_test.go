@@ -0,0 +1,130 @@
+package rediscache
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v7"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type fakePubSub struct {
+	mu   sync.Mutex
+	subs map[string][]chan<- string
+}
+
+func (p *fakePubSub) Subscribe(name string, ch chan<- string) error {
+	p.mu.Lock()
+	p.subs[name] = append(p.subs[name], ch)
+	p.mu.Unlock()
+
+	return nil
+}
+
+func (p *fakePubSub) Publish(name, message string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, ch := range p.subs[name] {
+		ch <- message
+	}
+
+	return nil
+}
+
+func (p *fakePubSub) RemoveSubscription(name string, ch chan<- string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	subs := p.subs[name]
+	for i, cur := range subs {
+		if cur == ch {
+			p.subs[name] = append(subs[:i:i], subs[i+1:]...)
+			break
+		}
+	}
+
+	return nil
+}
+
+func TestVersionedCache(t *testing.T) {
+	Convey("Given two caches with local cache sharing Redis", t, func() {
+		mr, err := miniredis.Run()
+		So(err, ShouldBeNil)
+
+		cli := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+		c1 := New(cli, nil, WithLocalCache())
+		c2 := New(cli, nil, WithLocalCache())
+		versionKey := func() string { return "key:version" }
+		computed := 0
+		get := func(c *Cache, val string) string {
+			var s string
+
+			So(c.VersionedCache("key", "lookup", &s, versionKey, func() (interface{}, error) {
+				computed++
+				return val, nil
+			}, nil, time.Hour), ShouldBeNil)
+
+			return s
+		}
+
+		Convey("value is served from L1 while its version is current", func() {
+			So(get(c1, "a"), ShouldEqual, "a")
+			mr.Del("key")
+			So(get(c1, "b"), ShouldEqual, "a")
+			So(computed, ShouldEqual, 1)
+		})
+		Convey("L1 value is not served after version is changed by another instance", func() {
+			So(get(c1, "a"), ShouldEqual, "a")
+			So(c2.InvalidateVersion(versionKey(), time.Hour), ShouldBeNil)
+			So(get(c1, "b"), ShouldEqual, "b")
+		})
+		Convey("L1 value stored with outdated version is not served", func() {
+			c1.setLocal("key", versionKey(), &cacheItem{Object: "stale", Version: "old"})
+			So(get(c1, "b"), ShouldEqual, "b")
+		})
+		Convey("invalidation removes L1 entries of subscribed instances", func() {
+			ps := &fakePubSub{subs: make(map[string][]chan<- string)}
+			So(c1.SubscribeInvalidations(ps, "invalidations"), ShouldBeNil)
+			So(c2.SubscribeInvalidations(ps, "invalidations"), ShouldBeNil)
+
+			get(c1, "a")
+			So(c1.LocalCache().Len(), ShouldEqual, 1)
+			So(c2.InvalidateVersion(versionKey(), time.Hour), ShouldBeNil)
+
+			for i := 0; i < 100 && c1.LocalCache().Len() > 0; i++ {
+				time.Sleep(time.Millisecond)
+			}
+
+			So(c1.LocalCache().Len(), ShouldEqual, 0)
+		})
+		Convey("Close removes subscription and stops processing invalidations", func() {
+			ps := &fakePubSub{subs: make(map[string][]chan<- string)}
+			So(c1.SubscribeInvalidations(ps, "invalidations"), ShouldBeNil)
+			So(c2.SubscribeInvalidations(ps, "invalidations"), ShouldBeNil)
+			So(ps.subs["invalidations"], ShouldHaveLength, 2)
+
+			So(c1.Close(), ShouldBeNil)
+			So(ps.subs["invalidations"], ShouldHaveLength, 1)
+
+			get(c1, "a")
+			So(c2.InvalidateVersion(versionKey(), time.Hour), ShouldBeNil)
+			time.Sleep(10 * time.Millisecond)
+			So(c1.LocalCache().Len(), ShouldEqual, 1)
+
+			So(c2.Close(), ShouldBeNil)
+			So(ps.subs["invalidations"], ShouldBeEmpty)
+			So(c2.Close(), ShouldBeNil)
+		})
+		Convey("SubscribeInvalidations requires local cache", func() {
+			c := New(cli, nil)
+			So(c.SubscribeInvalidations(&fakePubSub{}, "invalidations"), ShouldEqual, ErrLocalCacheDisabled)
+		})
+
+		So(c1.Close(), ShouldBeNil)
+		So(c2.Close(), ShouldBeNil)
+		mr.Close()
+	})
+}
@@ -11,3 +11,9 @@ type rediser interface {
 	Get(key string) *redis.StringCmd
 	Del(keys ...string) *redis.IntCmd
 }
+
+type pubsuber interface {
+	Subscribe(name string, ch chan<- string) error
+	Publish(name, message string) error
+	RemoveSubscription(name string, ch chan<- string) error
+}
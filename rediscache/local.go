@@ -0,0 +1,155 @@
+package rediscache
+
+import (
+	lcache "github.com/Syncano/pkg-go/v2/cache"
+)
+
+const invalidationBufferSize = 1000
+
+// localItem is an L1 cache entry holding serialized cacheItem along with version key it depends on.
+type localItem struct {
+	data       []byte
+	versionKey string
+}
+
+// initLocalCache creates L1 cache along with an index of its keys by version key.
+func (c *Cache) initLocalCache(opts []lcache.Option) {
+	opts = append([]lcache.Option{lcache.WithTTL(c.cfg.LocalCacheTimeout)}, opts...)
+
+	c.local = lcache.NewLRUCache(false, opts...)
+	c.localKeys = lcache.NewLRUSetCache(lcache.WithTTL(c.local.Config().TTL))
+
+	c.local.AddEvictionHandler(func(key string, val interface{}, reason lcache.EvictionReason) {
+		c.localKeys.Delete(val.(*localItem).versionKey, key)
+	})
+}
+
+// LocalCache returns L1 cache used when local cache is enabled through WithLocalCache or nil otherwise.
+func (c *Cache) LocalCache() *lcache.LRUCache {
+	return c.local
+}
+
+// SubscribeInvalidations subscribes to invalidation channel so that L1 entries are invalidated whenever
+// InvalidateVersion is called by any instance sharing the same channel. Requires WithLocalCache.
+// Previous subscription is removed. Subscription is removed on Close.
+func (c *Cache) SubscribeInvalidations(ps pubsuber, channel string) error {
+	if c.local == nil {
+		return ErrLocalCacheDisabled
+	}
+
+	if err := c.unsubscribeInvalidations(); err != nil {
+		return err
+	}
+
+	ch := make(chan string, invalidationBufferSize)
+	if err := ps.Subscribe(channel, ch); err != nil {
+		return err
+	}
+
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+
+	c.muPubSub.Lock()
+	c.pubsub = ps
+	c.channel = channel
+	c.invalidations = ch
+	c.stop = stop
+	c.stopped = stopped
+	c.muPubSub.Unlock()
+
+	go func() {
+		defer close(stopped)
+
+		for {
+			select {
+			case versionKey := <-ch:
+				c.invalidateLocal(versionKey)
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+// unsubscribeInvalidations removes subscription to invalidation channel and waits for its processing to stop.
+func (c *Cache) unsubscribeInvalidations() error {
+	c.muPubSub.Lock()
+	ps, channel, ch, stop, stopped := c.pubsub, c.channel, c.invalidations, c.stop, c.stopped
+	c.pubsub, c.channel, c.invalidations, c.stop, c.stopped = nil, "", nil, nil, nil
+	c.muPubSub.Unlock()
+
+	if ps == nil {
+		return nil
+	}
+
+	err := ps.RemoveSubscription(channel, ch)
+
+	close(stop)
+	<-stopped
+
+	return err
+}
+
+// Close removes subscription to invalidation channel and stops janitors of L1 cache.
+// Cache should not be used after it is closed.
+func (c *Cache) Close() error {
+	err := c.unsubscribeInvalidations()
+
+	if c.local != nil {
+		c.local.StopJanitor()
+		c.localKeys.StopJanitor()
+	}
+
+	return err
+}
+
+func (c *Cache) getLocal(cacheKey string, item *cacheItem) bool {
+	if c.local == nil {
+		return false
+	}
+
+	li, ok := c.local.Get(cacheKey).(*localItem)
+
+	return ok && c.codec.Unmarshal(li.data, item) == nil
+}
+
+func (c *Cache) setLocal(cacheKey, versionKey string, item *cacheItem) {
+	if c.local == nil {
+		return
+	}
+
+	data, err := c.codec.Marshal(item)
+	if err != nil {
+		return
+	}
+
+	// Set first so that index entry removed by eviction handler of replaced value is added back.
+	c.local.Set(cacheKey, &localItem{data: data, versionKey: versionKey})
+	c.localKeys.Add(versionKey, cacheKey)
+}
+
+func (c *Cache) invalidateLocal(versionKey string) {
+	if c.local == nil {
+		return
+	}
+
+	for _, key := range c.localKeys.Get(versionKey) {
+		c.local.Delete(key.(string))
+	}
+
+	c.localKeys.DeleteKey(versionKey)
+}
+
+func (c *Cache) publishInvalidation(versionKey string) error {
+	c.muPubSub.RLock()
+	ps, channel := c.pubsub, c.channel
+	c.muPubSub.RUnlock()
+
+	if ps == nil {
+		return nil
+	}
+
+	return ps.Publish(channel, versionKey)
+}
@@ -42,6 +42,39 @@ func (p *PubSub) Subscribe(name string, ch chan<- string) error {
 	return nil
 }
 
+// Publish posts message to given channel.
+func (p *PubSub) Publish(name, message string) error {
+	return p.cli.Publish(name, message).Err()
+}
+
+// RemoveSubscription stops sending messages of given channel to ch. Channel is unsubscribed from
+// when it has no subscriptions left.
+func (p *PubSub) RemoveSubscription(name string, ch chan<- string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	subs := p.subs[name]
+	for i, cur := range subs {
+		if cur == ch {
+			subs = append(subs[:i:i], subs[i+1:]...)
+			break
+		}
+	}
+
+	if len(subs) > 0 {
+		p.subs[name] = subs
+		return nil
+	}
+
+	delete(p.subs, name)
+
+	if p.pubsub == nil {
+		return nil
+	}
+
+	return p.pubsub.Unsubscribe(name)
+}
+
 func (p *PubSub) Unsubscribe(name string) error {
 	return p.pubsub.Unsubscribe(name)
 }
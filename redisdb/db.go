@@ -5,12 +5,24 @@ import (
 	"reflect"
 
 	"github.com/go-redis/redis/v7"
+	"github.com/pkg/errors"
 )
 
 var (
 	modelerType = reflect.TypeOf((*Modeler)(nil)).Elem()
 )
 
+var (
+	// ErrInvalidModel marks that model is nil, not a pointer to struct or slice of structs or doesn't implement Modeler.
+	ErrInvalidModel = errors.New("redis: invalid model")
+	// ErrInvalidPK marks that model does not define int pk field.
+	ErrInvalidPK = errors.New("redis: pk undefined on model or wrong type (int required)")
+	// ErrUnknownField marks that field is not defined on model.
+	ErrUnknownField = errors.New("redis: unknown field")
+	// ErrUnsavedObject marks that updateFields were passed to Save of an object that wasn't saved before.
+	ErrUnsavedObject = errors.New("redis: updateFields cannot be specified for unsaved object")
+)
+
 // DB represents Redis DB object.
 type DB struct {
 	redisCli *redis.Client
@@ -23,42 +35,71 @@ func New(cli *redis.Client) *DB {
 	}
 }
 
-// Model returns ctx for specified model and args.
-func (d *DB) Model(m interface{}, args map[string]interface{}) *DBCtx {
+// Register validates schemas of given models so that invalid definitions surface at startup.
+func Register(models ...interface{}) error {
+	for _, m := range models {
+		if _, _, err := modelTable(reflect.TypeOf(m)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// modelTable returns modeler and table for model type, either a pointer to struct or a slice of them.
+func modelTable(typ reflect.Type) (Modeler, *Table, error) {
+	if typ == nil {
+		return nil, nil, fmt.Errorf("%w: nil", ErrInvalidModel)
+	}
+
+	if typ.Kind() == reflect.Slice {
+		typ = typ.Elem()
+	}
+
+	if !typ.Implements(modelerType) {
+		return nil, nil, fmt.Errorf("%w: %s does not implement Modeler", ErrInvalidModel, typ)
+	}
+
+	modeler := reflect.Zero(typ).Interface().(Modeler)
+
+	table, err := GetTableE(indirectType(typ))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return modeler, table, nil
+}
+
+// NewModel returns ctx for specified model and args. Model has to be a pointer to struct or to slice of structs
+// implementing Modeler.
+func (d *DB) NewModel(m interface{}, args map[string]interface{}) (*DBCtx, error) {
 	v := reflect.ValueOf(m)
 	if !v.IsValid() {
-		panic("redis: Model(nil)")
+		return nil, fmt.Errorf("%w: Model(nil)", ErrInvalidModel)
 	}
 
 	if v.Kind() != reflect.Ptr {
-		panic(fmt.Sprintf("pg: Model(non-pointer %T)", m))
+		return nil, fmt.Errorf("%w: Model(non-pointer %T)", ErrInvalidModel, m)
 	}
 
-	var typ reflect.Type
-
 	if v.IsNil() {
-		panic("redis: Model(nil)")
+		return nil, fmt.Errorf("%w: Model(nil)", ErrInvalidModel)
 	}
 
-	typ = v.Type()
+	typ := v.Type()
 	v = v.Elem()
 
 	switch v.Kind() {
 	case reflect.Slice:
-		typ = v.Type().Elem()
+		typ = v.Type()
 	case reflect.Struct:
 	default:
-		panic(fmt.Sprintf("redis: Model(unsupported %s)", v.Type()))
-	}
-
-	if !typ.Implements(modelerType) {
-		panic(fmt.Sprintf("redis: Model %s does not implement Modeler", v.Type()))
+		return nil, fmt.Errorf("%w: Model(unsupported %s)", ErrInvalidModel, v.Type())
 	}
 
-	modeler := reflect.Zero(typ).Interface().(Modeler)
-
-	if typ.Kind() == reflect.Ptr {
-		typ = typ.Elem()
+	modeler, table, err := modelTable(typ)
+	if err != nil {
+		return nil, err
 	}
 
 	return &DBCtx{
@@ -66,7 +107,18 @@ func (d *DB) Model(m interface{}, args map[string]interface{}) *DBCtx {
 		args: args,
 
 		model: modeler,
-		table: GetTable(typ),
+		table: table,
 		value: v,
+	}, nil
+}
+
+// Model returns ctx for specified model and args. If model is invalid, error is returned by any operation on ctx,
+// see NewModel.
+func (d *DB) Model(m interface{}, args map[string]interface{}) *DBCtx {
+	c, err := d.NewModel(m, args)
+	if err != nil {
+		return &DBCtx{DB: d, err: err}
 	}
+
+	return c
 }
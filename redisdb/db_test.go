@@ -0,0 +1,110 @@
+package redisdb
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v7"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type testModel struct {
+	ID    int
+	Name  string
+	Owner string `redis:"owner,index"`
+}
+
+func (m *testModel) Key(args map[string]interface{}) string               { return "test" }
+func (m *testModel) ListArgs(args map[string]interface{}) string          { return "" }
+func (m *testModel) ListMaxSize(args map[string]interface{}) int          { return 100 }
+func (m *testModel) TTL(args map[string]interface{}) time.Duration        { return 0 }
+func (m *testModel) TrimmedTTL(args map[string]interface{}) time.Duration { return time.Hour }
+
+type noPKModel struct {
+	testModel
+	ID string
+}
+
+type notModel struct {
+	ID int
+}
+
+func newTestDB() (*DB, *miniredis.Miniredis) {
+	mr, err := miniredis.Run()
+	So(err, ShouldBeNil)
+
+	return New(redis.NewClient(&redis.Options{Addr: mr.Addr()})), mr
+}
+
+func TestRegister(t *testing.T) {
+	Convey("Register validates models", t, func() {
+		So(Register(&testModel{}, []*testModel{}), ShouldBeNil)
+		So(errors.Is(Register(&noPKModel{}), ErrInvalidPK), ShouldBeTrue)
+		So(errors.Is(Register(&notModel{}), ErrInvalidModel), ShouldBeTrue)
+		So(errors.Is(Register(nil), ErrInvalidModel), ShouldBeTrue)
+	})
+	Convey("GetTableE returns error for invalid type while GetTable panics", t, func() {
+		_, err := GetTableE(reflect.TypeOf(0))
+		So(errors.Is(err, ErrInvalidModel), ShouldBeTrue)
+		So(func() { GetTable(reflect.TypeOf(0)) }, ShouldPanic)
+		So(GetTable(reflect.TypeOf(testModel{})).Indexes, ShouldResemble, []string{"owner"})
+	})
+}
+
+func TestNewModel(t *testing.T) {
+	Convey("Given DB", t, func() {
+		db, mr := newTestDB()
+
+		Convey("NewModel rejects invalid models", func() {
+			n := 0
+
+			for _, m := range []interface{}{nil, (*testModel)(nil), testModel{}, &n, &notModel{}, &[]notModel{}} {
+				_, err := db.NewModel(m, nil)
+				So(errors.Is(err, ErrInvalidModel), ShouldBeTrue)
+			}
+
+			_, err := db.NewModel(&noPKModel{}, nil)
+			So(errors.Is(err, ErrInvalidPK), ShouldBeTrue)
+		})
+		Convey("NewModel accepts pointer to struct and to slice", func() {
+			_, err := db.NewModel(&testModel{}, nil)
+			So(err, ShouldBeNil)
+			_, err = db.NewModel(&[]*testModel{}, nil)
+			So(err, ShouldBeNil)
+		})
+		Convey("Model returns error of invalid model from operations", func() {
+			c := db.Model(&notModel{}, nil)
+			So(errors.Is(c.Find(1), ErrInvalidModel), ShouldBeTrue)
+			So(errors.Is(c.Save(nil), ErrInvalidModel), ShouldBeTrue)
+			So(errors.Is(c.Update(1, nil, nil), ErrInvalidModel), ShouldBeTrue)
+		})
+		Convey("operations reject wrong kind of model", func() {
+			So(errors.Is(db.Model(&[]*testModel{}, nil).Find(1), ErrInvalidModel), ShouldBeTrue)
+			So(errors.Is(db.Model(&testModel{}, nil).List(0, 0, 10, true, nil), ErrInvalidModel), ShouldBeTrue)
+		})
+		Convey("Save rejects unknown fields and updateFields of unsaved object", func() {
+			So(errors.Is(db.Model(&testModel{ID: 1}, nil).Save([]string{"unknown"}), ErrUnknownField), ShouldBeTrue)
+			So(db.Model(&testModel{}, nil).Save([]string{"name"}), ShouldEqual, ErrUnsavedObject)
+		})
+		Convey("Update rejects unknown fields", func() {
+			c := db.Model(&testModel{}, nil)
+			So(errors.Is(c.Update(1, map[string]interface{}{"unknown": 1}, nil), ErrUnknownField), ShouldBeTrue)
+			So(errors.Is(c.Update(1, nil, map[string]interface{}{"unknown": 1}), ErrUnknownField), ShouldBeTrue)
+		})
+		Convey("saved object can be found", func() {
+			o := &testModel{Name: "a"}
+			So(db.Model(o, nil).Save(nil), ShouldBeNil)
+			So(o.ID, ShouldEqual, 1)
+
+			found := &testModel{}
+			So(db.Model(found, nil).Find(1), ShouldBeNil)
+			So(found, ShouldResemble, o)
+			So(db.Model(found, nil).Find(2), ShouldEqual, ErrNotFound)
+		})
+
+		mr.Close()
+	})
+}
@@ -1,6 +1,7 @@
 package redisdb
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 	"strconv"
@@ -10,6 +11,7 @@ import (
 
 	"github.com/go-redis/redis/v7"
 	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
 )
 
 var (
@@ -20,7 +22,8 @@ var (
 )
 
 const (
-	updateRetries = 3
+	updateRetries  = 3
+	pruneBatchSize = 100
 )
 
 // DBCtx represents DB context.
@@ -31,17 +34,66 @@ type DBCtx struct {
 	model Modeler
 	table *Table
 	value reflect.Value
+	err   error
+}
+
+// check returns error if ctx was created for an invalid model or value is not of given kind.
+func (c *DBCtx) check(kind reflect.Kind) error {
+	if c.err != nil {
+		return c.err
+	}
+
+	if c.value.Kind() != kind {
+		return fmt.Errorf("%w: %s required, got %s", ErrInvalidModel, kind, c.value.Type())
+	}
+
+	return nil
+}
+
+// checkFields returns error if any of given fields is not defined on model.
+func (c *DBCtx) checkFields(fields []string) error {
+	for _, f := range fields {
+		if _, ok := c.table.Fields[f]; !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownField, f)
+		}
+	}
+
+	return nil
+}
+
+// startSpan starts a span named after model and operation and returns redis client bound to its context.
+func (c *DBCtx) startSpan(ctx context.Context, op string) (*redis.Client, *trace.Span) {
+	ctx, span := trace.StartSpan(ctx, fmt.Sprintf("redisdb.%s.%s", c.table.Name, op))
+
+	return c.redisCli.WithContext(ctx), span
+}
+
+// endSpan ends span marking it as failed if err is unexpected.
+func endSpan(span *trace.Span, err error) {
+	if err != nil && !errors.Is(err, ErrNotFound) && !errors.Is(err, ErrExpectedMismatch) {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeUnknown, Message: err.Error()})
+	}
+
+	span.End()
 }
 
 // Find selects one object with specified pk.
 func (c *DBCtx) Find(pk int) error {
-	if c.value.Kind() != reflect.Struct {
-		panic("redis: model is not a struct")
+	return c.FindContext(context.Background(), pk)
+}
+
+// FindContext selects one object with specified pk using given context.
+func (c *DBCtx) FindContext(ctx context.Context, pk int) (err error) {
+	if err := c.check(reflect.Struct); err != nil {
+		return err
 	}
 
+	cli, span := c.startSpan(ctx, "Find")
+	defer func() { endSpan(span, err) }()
+
 	objectKey := c.getObjectKey(pk)
 
-	r, err := c.redisCli.HGetAll(objectKey).Result()
+	r, err := cli.HGetAll(objectKey).Result()
 	if err != nil {
 		return err
 	}
@@ -50,12 +102,18 @@ func (c *DBCtx) Find(pk int) error {
 		return ErrNotFound
 	}
 
+	return c.loadObject(r)
+}
+
+// loadObject sets fields of model from values of object hash.
+func (c *DBCtx) loadObject(r map[string]string) error {
 	val := c.value
 
 	var (
 		name, v string
 		ok      bool
 		vv      reflect.Value
+		err     error
 	)
 
 	for _, f := range c.table.Fields {
@@ -63,7 +121,9 @@ func (c *DBCtx) Find(pk int) error {
 		v, ok = r[name]
 
 		if ok {
-			vv = reflect.ValueOf(f.Adapter.Load(v))
+			if vv, err = f.load(v); err != nil {
+				return err
+			}
 		} else {
 			if !f.HasDefault() {
 				continue
@@ -81,7 +141,7 @@ func (c *DBCtx) Value() reflect.Value {
 	return c.value
 }
 
-func (c *DBCtx) listKeys(minPK, maxPK, limit int, isOrderAsc bool) ([]string, error) {
+func (c *DBCtx) listKeys(cli *redis.Client, listKey string, minPK, maxPK, limit int, isOrderAsc bool) ([]string, error) {
 	var (
 		min, max string
 	)
@@ -99,7 +159,6 @@ func (c *DBCtx) listKeys(minPK, maxPK, limit int, isOrderAsc bool) ([]string, er
 	}
 
 	l := c.model.ListMaxSize(c.args)
-	listKey := c.getListKey()
 
 	if limit > l {
 		limit = l
@@ -108,10 +167,10 @@ func (c *DBCtx) listKeys(minPK, maxPK, limit int, isOrderAsc bool) ([]string, er
 	opt := &redis.ZRangeBy{Max: max, Min: min, Count: int64(limit)}
 
 	if isOrderAsc {
-		return c.redisCli.ZRangeByScore(listKey, opt).Result()
+		return cli.ZRangeByScore(listKey, opt).Result()
 	}
 
-	return c.redisCli.ZRevRangeByScore(listKey, opt).Result()
+	return cli.ZRevRangeByScore(listKey, opt).Result()
 }
 
 func (c *DBCtx) createSlice(objs []reflect.Value) {
@@ -170,35 +229,148 @@ func (c *DBCtx) getListKey() string {
 
 // List selects a list of objects.
 func (c *DBCtx) List(minPK, maxPK, limit int, isOrderAsc bool, skippedFields []string) error {
-	if c.value.Kind() != reflect.Slice {
-		panic("redis: model is not a struct")
+	return c.ListContext(context.Background(), minPK, maxPK, limit, isOrderAsc, skippedFields)
+}
+
+// ListContext selects a list of objects using given context.
+func (c *DBCtx) ListContext(ctx context.Context, minPK, maxPK, limit int, isOrderAsc bool, skippedFields []string) error {
+	if err := c.check(reflect.Slice); err != nil {
+		return err
 	}
 
+	return c.list(ctx, "List", c.getListKey(), minPK, maxPK, limit, isOrderAsc, skippedFields)
+}
+
+// list selects a list of objects from sorted set at listKey.
+func (c *DBCtx) list(ctx context.Context, op, listKey string, minPK, maxPK, limit int, isOrderAsc bool,
+	skippedFields []string) (err error) {
 	if minPK < 0 || maxPK < 0 {
 		c.createSlice(nil)
 		return nil
 	}
 
-	keysList, err := c.listKeys(minPK, maxPK, limit, isOrderAsc)
+	cli, span := c.startSpan(ctx, op)
+	defer func() { endSpan(span, err) }()
+
+	keysList, err := c.listKeys(cli, listKey, minPK, maxPK, limit, isOrderAsc)
 	if err != nil {
 		return err
 	}
 
+	objs, err := c.fetchObjects(cli, keysList, c.getFetchedFields(skippedFields))
+	if err != nil {
+		return err
+	}
+
+	c.createSlice(objs)
+
+	return nil
+}
+
+// getFetchedFields returns fields to fetch for a list of objects. Pk is always fetched so that hashes that are gone
+// can be told apart from ones with all fetched fields unset.
+func (c *DBCtx) getFetchedFields(skippedFields []string) []string {
 	fields := c.getFields(nil, skippedFields)
+	if !containsString(fields, pkName) {
+		fields = append(fields, pkName)
+	}
+
+	return fields
+}
+
+// Prune removes list members of objects that are gone, e.g. expired. List skips such members but leaves them
+// in place so that it doesn't write. Returns number of removed members.
+func (c *DBCtx) Prune() (int, error) {
+	return c.PruneContext(context.Background())
+}
+
+// PruneContext removes list members of objects that are gone using given context, see Prune.
+func (c *DBCtx) PruneContext(ctx context.Context) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+
+	return c.prune(ctx, "Prune", c.getListKey())
+}
+
+// prune removes members of sorted set at listKey whose objects are gone.
+func (c *DBCtx) prune(ctx context.Context, op, listKey string) (removed int, err error) {
+	cli, span := c.startSpan(ctx, op)
+	defer func() { endSpan(span, err) }()
+
+	var (
+		keys []string
+		cmds []redis.Cmder
+	)
+
+	for start := int64(0); ; {
+		keys, err = cli.ZRange(listKey, start, start+pruneBatchSize-1).Result()
+		if err != nil || len(keys) == 0 {
+			return removed, err
+		}
+
+		cmds, err = cli.Pipelined(func(pipe redis.Pipeliner) error {
+			for _, key := range keys {
+				pipe.Exists(key)
+			}
+			return nil
+		})
+		if err != nil {
+			return removed, err
+		}
+
+		var missing []string
+
+		for i, cmd := range cmds {
+			if cmd.(*redis.IntCmd).Val() == 0 {
+				missing = append(missing, keys[i])
+			}
+		}
+
+		if err = removeMissing(cli, listKey, missing); err != nil {
+			return removed, err
+		}
+
+		removed += len(missing)
+
+		if len(keys) < pruneBatchSize {
+			return removed, nil
+		}
+
+		// Removed members no longer take up ranks.
+		start += int64(len(keys) - len(missing))
+	}
+}
+
+// removeMissing removes keys of objects that are gone from sorted set at listKey.
+func removeMissing(cli *redis.Client, listKey string, missing []string) error {
+	if len(missing) == 0 {
+		return nil
+	}
+
+	members := make([]interface{}, len(missing))
+	for i, m := range missing {
+		members[i] = m
+	}
 
-	ret, err := c.redisCli.Pipelined(func(pipe redis.Pipeliner) error {
-		for _, key := range keysList {
+	return cli.ZRem(listKey, members...).Err()
+}
+
+// fetchObjects loads given fields of objects at keys in order. Objects that have none of these fields set are
+// skipped as they are gone.
+func (c *DBCtx) fetchObjects(cli *redis.Client, keys, fields []string) (objs []reflect.Value, err error) {
+	ret, err := cli.Pipelined(func(pipe redis.Pipeliner) error {
+		for _, key := range keys {
 			pipe.HMGet(key, fields...)
 		}
 		return nil
 	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	var (
 		objVal, structVal, fieldVal reflect.Value
-		objs                        []reflect.Value
 		v                           []interface{}
 		empty                       bool
 		field                       *Field
@@ -209,7 +381,7 @@ func (c *DBCtx) List(minPK, maxPK, limit int, isOrderAsc bool, skippedFields []s
 
 		v, err = cmd.(*redis.SliceCmd).Result()
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		// We need a struct so check if it's a Ptr.
@@ -230,7 +402,9 @@ func (c *DBCtx) List(minPK, maxPK, limit int, isOrderAsc bool, skippedFields []s
 
 				fieldVal = field.Default()
 			} else {
-				fieldVal = reflect.ValueOf(field.Adapter.Load(v[i].(string)))
+				if fieldVal, err = field.load(v[i].(string)); err != nil {
+					return nil, err
+				}
 				empty = false
 			}
 
@@ -242,25 +416,46 @@ func (c *DBCtx) List(minPK, maxPK, limit int, isOrderAsc bool, skippedFields []s
 		}
 	}
 
-	c.createSlice(objs)
-
-	return nil
+	return objs, nil
 }
 
-func (c *DBCtx) trimList(cmds []redis.Cmder) error {
+// trimList removes objects trimmed from list from indexes and sets their ttl to trimmed ttl.
+func (c *DBCtx) trimList(cli *redis.Client, cmds []redis.Cmder) error {
 	trimmedTTL := c.model.TrimmedTTL(c.args)
-	if trimmedTTL <= 0 {
+	indexes := c.table.Indexes
+
+	if trimmedTTL <= 0 && len(indexes) == 0 {
 		return nil
 	}
 
 	keys, err := cmds[len(cmds)-2].(*redis.StringSliceCmd).Result()
-	if err != nil {
+	if err != nil || len(keys) == 0 {
 		return err
 	}
 
-	_, err = c.redisCli.Pipelined(func(pipe redis.Pipeliner) error {
-		for _, key := range keys {
-			pipe.Expire(key, trimmedTTL)
+	var indexed []redis.Cmder
+
+	if len(indexes) > 0 {
+		indexed, err = cli.Pipelined(func(pipe redis.Pipeliner) error {
+			for _, key := range keys {
+				pipe.HMGet(key, indexes...)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = cli.Pipelined(func(pipe redis.Pipeliner) error {
+		for i, key := range keys {
+			if indexed != nil {
+				c.unindex(pipe, key, indexedValues(indexes, indexed[i].(*redis.SliceCmd).Val()))
+			}
+
+			if trimmedTTL > 0 {
+				pipe.Expire(key, trimmedTTL)
+			}
 		}
 		return nil
 	})
@@ -268,30 +463,69 @@ func (c *DBCtx) trimList(cmds []redis.Cmder) error {
 	return err
 }
 
-func (c *DBCtx) saveObject(pipe redis.Pipeliner, pk int, objectKey string, fields []string, saved bool, ttl time.Duration) bool {
+// dumpFields returns dumped values of given fields of model.
+func (c *DBCtx) dumpFields(fields []string) (map[string]string, error) {
+	values := make(map[string]string, len(fields))
+
+	for _, f := range fields {
+		field := c.table.Fields[f]
+
+		s, err := field.dump(field.Value(c.value).Interface())
+		if err != nil {
+			return nil, err
+		}
+
+		values[f] = s
+	}
+
+	return values, nil
+}
+
+// dumpValues returns given values of model fields dumped.
+func (c *DBCtx) dumpValues(values map[string]interface{}) (map[string]string, error) {
+	dumped := make(map[string]string, len(values))
+
+	for f, v := range values {
+		s, err := c.table.Fields[f].dump(v)
+		if err != nil {
+			return nil, err
+		}
+
+		dumped[f] = s
+	}
+
+	return dumped, nil
+}
+
+func (c *DBCtx) saveObject(pipe redis.Pipeliner, pk int, objectKey string, values map[string]string, saved bool,
+	ttl time.Duration) bool {
 	var (
-		field    *Field
-		v        reflect.Value
-		s        string
 		trimming bool
+		indexed  map[string]string
 	)
 
-	for _, f := range fields {
-		field = c.table.Fields[f]
-		v = field.Value(c.value)
-		s = field.Adapter.Dump(v.Interface())
-
+	for f, s := range values {
 		if s != "" {
 			pipe.HSet(objectKey, f, s)
 		} else if saved {
 			pipe.HDel(objectKey, f)
 		}
+
+		if c.table.Fields[f].Indexed {
+			if indexed == nil {
+				indexed = make(map[string]string)
+			}
+
+			indexed[f] = s
+		}
 	}
 
 	if ttl > 0 {
 		pipe.Expire(objectKey, ttl)
 	}
 
+	c.index(pipe, pk, objectKey, indexed, ttl)
+
 	if !saved {
 		// Save to list if not added already.
 		listKey := c.getListKey()
@@ -313,30 +547,51 @@ func (c *DBCtx) saveObject(pipe redis.Pipeliner, pk int, objectKey string, field
 	return trimming
 }
 
+// Save saves object, assigning it a new pk if it wasn't saved before. If updateFields are specified,
+// only these fields are saved.
 func (c *DBCtx) Save(updateFields []string) error {
-	if c.value.Kind() != reflect.Struct {
-		panic("redis: model is not a struct")
+	return c.SaveContext(context.Background(), updateFields)
+}
+
+// SaveContext saves object using given context, see Save.
+func (c *DBCtx) SaveContext(ctx context.Context, updateFields []string) (err error) {
+	if err := c.check(reflect.Struct); err != nil {
+		return err
 	}
 
+	if err := c.checkFields(updateFields); err != nil {
+		return err
+	}
+
+	cli, span := c.startSpan(ctx, "Save")
+	defer func() { endSpan(span, err) }()
+
 	ttl := c.model.TTL(c.args)
 	pk := c.table.PK(c.value)
 	saved := pk != 0
 
+	if !saved && len(updateFields) > 0 {
+		return ErrUnsavedObject
+	}
+
+	fields := c.getFields(updateFields, nil)
+
+	values, err := c.dumpFields(fields)
+	if err != nil {
+		return err
+	}
+
 	// Set ID if not saved.
 	if !saved {
-		if len(updateFields) > 0 {
-			panic("redis: updateFields cannot be specified for unsaved object")
-		}
-
 		seqKey := fmt.Sprintf("%s:seq", c.model.Key(c.args))
 
-		v, err := c.redisCli.Incr(seqKey).Result()
+		v, err := cli.Incr(seqKey).Result()
 		if err != nil {
 			return err
 		}
 
 		if ttl > 0 {
-			if err := c.redisCli.Expire(seqKey, ttl*2).Err(); err != nil {
+			if err := cli.Expire(seqKey, ttl*2).Err(); err != nil {
 				return err
 			}
 		}
@@ -344,16 +599,30 @@ func (c *DBCtx) Save(updateFields []string) error {
 		i := int(v)
 		c.table.SetPK(c.value, i)
 		pk = i
+		values[pkName] = strconv.Itoa(pk)
 	}
 
 	// Save object.
 	objectKey := c.getObjectKey(pk)
-	fields := c.getFields(updateFields, nil)
+
+	// Indexes of saved object are updated in a transaction so that old values are removed from them.
+	if indexed := c.indexedFields(fields); saved && len(indexed) > 0 {
+		return c.watchIndexed(cli, objectKey, indexed, func(tx *redis.Tx, old map[string]string) error {
+			_, err := tx.TxPipelined(func(pipe redis.Pipeliner) error {
+				c.unindex(pipe, objectKey, old)
+				c.saveObject(pipe, pk, objectKey, values, saved, ttl)
+
+				return nil
+			})
+
+			return err
+		})
+	}
 
 	var trimming bool
 
-	cmds, err := c.redisCli.Pipelined(func(pipe redis.Pipeliner) error {
-		trimming = c.saveObject(pipe, pk, objectKey, fields, saved, ttl)
+	cmds, err := cli.Pipelined(func(pipe redis.Pipeliner) error {
+		trimming = c.saveObject(pipe, pk, objectKey, values, saved, ttl)
 		return nil
 	})
 	if err != nil {
@@ -361,21 +630,44 @@ func (c *DBCtx) Save(updateFields []string) error {
 	}
 
 	if trimming {
-		return c.trimList(cmds)
+		return c.trimList(cli, cmds)
 	}
 
 	return nil
 }
 
+// Delete deletes object and removes it from list.
 func (c *DBCtx) Delete() error {
-	if c.value.Kind() != reflect.Struct {
-		panic("redis: model is not a struct")
+	return c.DeleteContext(context.Background())
+}
+
+// DeleteContext deletes object using given context, see Delete.
+func (c *DBCtx) DeleteContext(ctx context.Context) (err error) {
+	if err := c.check(reflect.Struct); err != nil {
+		return err
 	}
 
+	cli, span := c.startSpan(ctx, "Delete")
+	defer func() { endSpan(span, err) }()
+
 	objectKey := c.getObjectKey(c.table.PK(c.value))
 	listKey := c.getListKey()
 
-	_, err := c.redisCli.Pipelined(func(pipe redis.Pipeliner) error {
+	if len(c.table.Indexes) > 0 {
+		return c.watchIndexed(cli, objectKey, c.table.Indexes, func(tx *redis.Tx, old map[string]string) error {
+			_, err := tx.TxPipelined(func(pipe redis.Pipeliner) error {
+				pipe.Del(objectKey)
+				pipe.ZRem(listKey, objectKey)
+				c.unindex(pipe, objectKey, old)
+
+				return nil
+			})
+
+			return err
+		})
+	}
+
+	_, err = cli.Pipelined(func(pipe redis.Pipeliner) error {
 		pipe.Del(objectKey)
 		pipe.ZRem(listKey, objectKey)
 		return nil
@@ -384,56 +676,109 @@ func (c *DBCtx) Delete() error {
 	return err
 }
 
+// Update updates fields of object with specified pk if its current values match expected ones.
+// Returns ErrNotFound if model has indexed fields and object doesn't exist, so that a partial object is not indexed.
 func (c *DBCtx) Update(pk int, updated, expected map[string]interface{}) error {
+	return c.UpdateContext(context.Background(), pk, updated, expected)
+}
+
+// UpdateContext updates object using given context, see Update.
+func (c *DBCtx) UpdateContext(ctx context.Context, pk int, updated, expected map[string]interface{}) (err error) {
+	if c.err != nil {
+		return c.err
+	}
+
+	fields := make([]string, 0, len(updated)+len(expected))
+	for f := range updated {
+		fields = append(fields, f)
+	}
+
+	indexed := c.indexedFields(fields)
+
+	for f := range expected {
+		fields = append(fields, f)
+	}
+
+	if err := c.checkFields(fields); err != nil {
+		return err
+	}
+
+	updatedValues, err := c.dumpValues(updated)
+	if err != nil {
+		return err
+	}
+
+	expectedValues, err := c.dumpValues(expected)
+	if err != nil {
+		return err
+	}
+
+	cli, span := c.startSpan(ctx, "Update")
+	defer func() { endSpan(span, err) }()
+
 	objectKey := c.getObjectKey(pk)
+	checkExists := len(c.table.Indexes) > 0
 
 	var watch []string
-	if len(expected) > 0 {
+	if len(expected) > 0 || checkExists {
 		watch = append(watch, objectKey)
 	}
 
-	_, err := util.RetryWithCritical(updateRetries, 0, func() (bool, error) {
-		err := c.redisCli.Watch(func(tx *redis.Tx) error {
+	_, err = util.RetryWithCritical(updateRetries, 0, func() (bool, error) {
+		err := cli.Watch(func(tx *redis.Tx) error {
 			var (
 				cur string
 				e   error
 			)
-			// Check expected first.
-			for k, v := range expected {
+			// Check that object exists so that update doesn't create a partial one in indexes.
+			if checkExists {
+				n, e := tx.Exists(objectKey).Result()
+				if e != nil {
+					return e
+				}
+				if n == 0 {
+					return ErrNotFound
+				}
+			}
+
+			// Check expected values.
+			for k, v := range expectedValues {
 				cur, e = tx.HGet(objectKey, k).Result()
 				if e != nil {
 					return e
 				}
-				if cur != c.table.Fields[k].Adapter.Dump(v) {
+				if cur != v {
 					return ErrExpectedMismatch
 				}
 			}
 
-			// Process actual saving.
-			_, e = tx.Pipelined(func(pipe redis.Pipeliner) error {
-				var (
-					field *Field
-					ok    bool
-					s     string
-				)
-				for f, v := range updated {
-					field, ok = c.table.Fields[f]
-					if !ok {
-						panic(fmt.Sprintf("redis: undefined field %s", f))
-					}
-					s = field.Adapter.Dump(v)
+			old, e := readIndexed(tx, objectKey, indexed)
+			if e != nil {
+				return e
+			}
 
+			// Process actual saving.
+			_, e = tx.TxPipelined(func(pipe redis.Pipeliner) error {
+				vals := make(map[string]string, len(indexed))
+				for f, s := range updatedValues {
 					if s != "" {
 						pipe.HSet(objectKey, f, s)
 					} else {
 						pipe.HDel(objectKey, f)
 					}
+
+					if c.table.Fields[f].Indexed {
+						vals[f] = s
+					}
 				}
 				ttl := c.model.TTL(c.args)
 				if ttl > 0 {
 					pipe.Expire(objectKey, ttl)
 				}
 
+				c.unindex(pipe, objectKey, old)
+				c.index(pipe, pk, objectKey, vals, ttl)
+
 				return nil
 			})
 			return e
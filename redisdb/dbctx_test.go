@@ -0,0 +1,107 @@
+package redisdb
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v7"
+	. "github.com/smartystreets/goconvey/convey"
+	"go.opencensus.io/trace"
+)
+
+type spanExporter struct {
+	mu    sync.Mutex
+	spans []*trace.SpanData
+}
+
+func (e *spanExporter) ExportSpan(s *trace.SpanData) {
+	e.mu.Lock()
+	e.spans = append(e.spans, s)
+	e.mu.Unlock()
+}
+
+// spanHook records names of spans found in contexts of processed commands.
+type spanHook struct {
+	names []string
+}
+
+func (h *spanHook) record(ctx context.Context) {
+	if span := trace.FromContext(ctx); span != nil {
+		h.names = append(h.names, span.String())
+	}
+}
+
+func (h *spanHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	h.record(ctx)
+	return ctx, nil
+}
+
+func (h *spanHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	return nil
+}
+
+func (h *spanHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	h.record(ctx)
+	return ctx, nil
+}
+
+func (h *spanHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	return nil
+}
+
+func TestTracing(t *testing.T) {
+	Convey("Given DB with span exporter", t, func() {
+		mr, err := miniredis.Run()
+		So(err, ShouldBeNil)
+
+		exp := &spanExporter{}
+		trace.RegisterExporter(exp)
+		trace.ApplyConfig(trace.Config{DefaultSampler: trace.AlwaysSample()})
+
+		hook := &spanHook{}
+		cli := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+		cli.AddHook(hook)
+		db := New(cli)
+
+		Convey("operations are traced in spans named after model and passed to redis", func() {
+			o := &testModel{Name: "a"}
+			So(db.Model(o, nil).Save(nil), ShouldBeNil)
+			So(db.Model(o, nil).Find(1), ShouldBeNil)
+			So(db.Model(o, nil).Find(2), ShouldEqual, ErrNotFound)
+
+			exp.mu.Lock()
+			defer exp.mu.Unlock()
+
+			So(exp.spans, ShouldHaveLength, 3)
+			So(exp.spans[0].Name, ShouldEqual, "redisdb.testModel.Save")
+			So(exp.spans[1].Name, ShouldEqual, "redisdb.testModel.Find")
+			So(exp.spans[2].Status.Code, ShouldEqual, trace.StatusCodeOK)
+			So(hook.names, ShouldNotBeEmpty)
+			So(hook.names[len(hook.names)-1], ShouldContainSubstring, "redisdb.testModel.Find")
+		})
+		Convey("expected errors don't mark span as failed even if wrapped", func() {
+			for _, err := range []error{ErrNotFound, fmt.Errorf("wrapped: %w", ErrExpectedMismatch)} {
+				_, span := trace.StartSpan(context.Background(), "expected")
+				endSpan(span, err)
+			}
+
+			_, span := trace.StartSpan(context.Background(), "unexpected")
+			endSpan(span, ErrInvalidValue)
+
+			exp.mu.Lock()
+			defer exp.mu.Unlock()
+
+			So(exp.spans, ShouldHaveLength, 3)
+			So(exp.spans[0].Status.Code, ShouldEqual, trace.StatusCodeOK)
+			So(exp.spans[1].Status.Code, ShouldEqual, trace.StatusCodeOK)
+			So(exp.spans[2].Status.Code, ShouldEqual, trace.StatusCodeUnknown)
+		})
+
+		trace.UnregisterExporter(exp)
+		trace.ApplyConfig(trace.Config{DefaultSampler: trace.ProbabilitySampler(1e-4)})
+		mr.Close()
+	})
+}
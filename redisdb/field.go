@@ -1,34 +1,59 @@
 package redisdb
 
 import (
+	"fmt"
 	"reflect"
 	"strconv"
+	"strings"
 	"time"
 
 	jsoniter "github.com/json-iterator/go"
+	"github.com/pkg/errors"
 )
 
 // FieldAdapter is an interface for Redis model field adapter.
+//
+// Deprecated: implement FieldAdapterE so that load and dump errors are not lost.
 type FieldAdapter interface {
 	Load(value string) interface{}
 	Dump(value interface{}) string
 }
 
-const datetimeFormat = "2006-01-02T15:04:05.000000Z"
+// FieldAdapterE is an interface for Redis model field adapter. Dumping to empty string removes field from object.
+type FieldAdapterE interface {
+	Load(value string) (interface{}, error)
+	Dump(value interface{}) (string, error)
+}
+
+const (
+	datetimeFormat = "2006-01-02T15:04:05.000000Z"
+	// pointerPrefix is prepended to values of non-nil pointer fields so that pointer to zero value is not stored
+	// as an empty string, which stands for nil.
+	pointerPrefix = "="
+)
 
 var (
-	fieldAdapterType = reflect.TypeOf((*FieldAdapter)(nil)).Elem()
-	timeType         = reflect.TypeOf((*time.Time)(nil)).Elem()
-	jsonAdapter      = &jsonFieldAdapter{}
-	timeAdapter      = &datetimeFieldAdapter{datetimeFormat: datetimeFormat}
+	// ErrInvalidValue marks that field value could not be loaded or dumped by its adapter.
+	ErrInvalidValue = errors.New("redis: invalid field value")
+
+	fieldAdapterType  = reflect.TypeOf((*FieldAdapter)(nil)).Elem()
+	fieldAdapterEType = reflect.TypeOf((*FieldAdapterE)(nil)).Elem()
+	timeType          = reflect.TypeOf((*time.Time)(nil)).Elem()
+	durationType      = reflect.TypeOf(time.Duration(0))
+	timeAdapter       = &datetimeFieldAdapter{datetimeFormat: datetimeFormat}
 )
 
 // Field represents a Redis model field.
 type Field struct {
-	Name    string
-	Field   reflect.StructField
-	Type    reflect.Type
+	Name  string
+	Field reflect.StructField
+	Type  reflect.Type
+	// Adapter loads and dumps field value ignoring errors, see AdapterE.
 	Adapter FieldAdapter
+	// AdapterE loads and dumps field value reporting invalid values.
+	AdapterE FieldAdapterE
+	// Indexed is true for fields declared with index option, e.g. `redis:"owner,index"`.
+	Indexed bool
 
 	def string
 }
@@ -38,9 +63,10 @@ func (f *Field) Value(v reflect.Value) reflect.Value {
 	return v.FieldByIndex(f.Field.Index)
 }
 
-// Default returns default value for that field.
+// Default returns default value for that field. Default is validated when table is created.
 func (f *Field) Default() reflect.Value {
-	return reflect.ValueOf(f.Adapter.Load(f.def))
+	v, _ := f.load(f.def)
+	return v
 }
 
 // HasDefault returns true if default is specified.
@@ -48,47 +74,204 @@ func (f *Field) HasDefault() bool {
 	return f.def != ""
 }
 
-var adaptersMap = map[reflect.Kind]FieldAdapter{
-	reflect.String: &stringFieldAdapter{},
-	reflect.Int:    &integerFieldAdapter{},
-	reflect.Map:    jsonAdapter,
-	reflect.Slice:  jsonAdapter,
-	reflect.Bool:   &boolFieldAdapter{},
+// load returns value loaded by field adapter converted to field type.
+func (f *Field) load(s string) (reflect.Value, error) {
+	x, err := f.AdapterE.Load(s)
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("%w: %s: %v", ErrInvalidValue, f.Name, err)
+	}
+
+	return convertValue(x, f.Field.Type), nil
 }
 
-func getAdapter(sf *reflect.StructField) FieldAdapter {
-	if sf.Type == timeType {
+// dump returns value dumped by field adapter.
+func (f *Field) dump(value interface{}) (string, error) {
+	s, err := f.AdapterE.Dump(value)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s: %v", ErrInvalidValue, f.Name, err)
+	}
+
+	return s, nil
+}
+
+// convertValue returns x as a value of given type if it's convertible, e.g. int64 to a named int type.
+func convertValue(x interface{}, typ reflect.Type) reflect.Value {
+	v := reflect.ValueOf(x)
+	if !v.IsValid() {
+		return reflect.Zero(typ)
+	}
+
+	if v.Type() != typ && v.Type().ConvertibleTo(typ) {
+		v = v.Convert(typ)
+	}
+
+	return v
+}
+
+func errUnsupportedValue(value interface{}) error {
+	return fmt.Errorf("unsupported value %T", value)
+}
+
+var adaptersMap = map[reflect.Kind]FieldAdapterE{
+	reflect.String:  &stringFieldAdapter{},
+	reflect.Int:     &intFieldAdapter{bitSize: strconv.IntSize},
+	reflect.Int8:    &intFieldAdapter{bitSize: 8},
+	reflect.Int16:   &intFieldAdapter{bitSize: 16},
+	reflect.Int32:   &intFieldAdapter{bitSize: 32},
+	reflect.Int64:   &intFieldAdapter{bitSize: 64},
+	reflect.Uint:    &uintFieldAdapter{bitSize: strconv.IntSize},
+	reflect.Uint8:   &uintFieldAdapter{bitSize: 8},
+	reflect.Uint16:  &uintFieldAdapter{bitSize: 16},
+	reflect.Uint32:  &uintFieldAdapter{bitSize: 32},
+	reflect.Uint64:  &uintFieldAdapter{bitSize: 64},
+	reflect.Float32: &floatFieldAdapter{bitSize: 32},
+	reflect.Float64: &floatFieldAdapter{bitSize: 64},
+	reflect.Bool:    &boolFieldAdapter{},
+}
+
+func getAdapter(typ reflect.Type) FieldAdapterE {
+	switch typ {
+	case timeType:
 		return timeAdapter
+	case durationType:
+		return &durationFieldAdapter{}
+	}
+
+	// Adapter types are used through a pointer to their zero value.
+	if typ.Kind() != reflect.Ptr {
+		switch ptr := reflect.PtrTo(typ); {
+		case ptr.Implements(fieldAdapterEType):
+			return reflect.New(typ).Interface().(FieldAdapterE)
+		case ptr.Implements(fieldAdapterType):
+			return &legacyFieldAdapter{reflect.New(typ).Interface().(FieldAdapter)}
+		}
+	}
+
+	switch typ.Kind() {
+	case reflect.Ptr:
+		if elem := getAdapter(typ.Elem()); elem != nil {
+			return &pointerFieldAdapter{typ: typ.Elem(), elem: elem}
+		}
+
+		return nil
+	case reflect.Slice:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			return &bytesFieldAdapter{}
+		}
+
+		return &jsonFieldAdapter{typ: typ}
+	case reflect.Map, reflect.Array, reflect.Struct, reflect.Interface:
+		return &jsonFieldAdapter{typ: typ}
 	}
 
-	if sf.Type.Implements(fieldAdapterType) {
-		return reflect.New(sf.Type).Interface().(FieldAdapter)
+	return adaptersMap[typ.Kind()]
+}
+
+// legacyFieldAdapter adapts FieldAdapter that doesn't report errors.
+type legacyFieldAdapter struct {
+	FieldAdapter
+}
+
+func (f *legacyFieldAdapter) Load(value string) (interface{}, error) {
+	return f.FieldAdapter.Load(value), nil
+}
+
+func (f *legacyFieldAdapter) Dump(value interface{}) (string, error) {
+	return f.FieldAdapter.Dump(value), nil
+}
+
+// errorlessFieldAdapter adapts FieldAdapterE to FieldAdapter, invalid values are loaded as nil and dumped as
+// an empty string.
+type errorlessFieldAdapter struct {
+	FieldAdapterE
+}
+
+func (f *errorlessFieldAdapter) Load(value string) interface{} {
+	x, _ := f.FieldAdapterE.Load(value)
+	return x
+}
+
+func (f *errorlessFieldAdapter) Dump(value interface{}) string {
+	s, _ := f.FieldAdapterE.Dump(value)
+	return s
+}
+
+// toFieldAdapter returns FieldAdapter for given adapter, legacy adapters are returned unwrapped.
+func toFieldAdapter(adapter FieldAdapterE) FieldAdapter {
+	if a, ok := adapter.(*legacyFieldAdapter); ok {
+		return a.FieldAdapter
 	}
 
-	return adaptersMap[sf.Type.Kind()]
+	return &errorlessFieldAdapter{adapter}
 }
 
 // String
 type stringFieldAdapter struct{}
 
-func (f *stringFieldAdapter) Load(value string) interface{} {
-	return value
+func (f *stringFieldAdapter) Load(value string) (interface{}, error) {
+	return value, nil
 }
 
-func (f *stringFieldAdapter) Dump(value interface{}) string {
-	return value.(string)
+func (f *stringFieldAdapter) Dump(value interface{}) (string, error) {
+	if v := reflect.ValueOf(value); v.Kind() == reflect.String {
+		return v.String(), nil
+	}
+
+	return "", errUnsupportedValue(value)
 }
 
 // Integer
-type integerFieldAdapter struct{}
+type intFieldAdapter struct {
+	bitSize int
+}
 
-func (f *integerFieldAdapter) Load(value string) interface{} {
-	v, _ := strconv.Atoi(value)
-	return v
+func (f *intFieldAdapter) Load(value string) (interface{}, error) {
+	return strconv.ParseInt(value, 10, f.bitSize)
+}
+
+func (f *intFieldAdapter) Dump(value interface{}) (string, error) {
+	switch v := reflect.ValueOf(value); v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	}
+
+	return "", errUnsupportedValue(value)
 }
 
-func (f *integerFieldAdapter) Dump(value interface{}) string {
-	return strconv.Itoa(value.(int))
+// Unsigned integer
+type uintFieldAdapter struct {
+	bitSize int
+}
+
+func (f *uintFieldAdapter) Load(value string) (interface{}, error) {
+	return strconv.ParseUint(value, 10, f.bitSize)
+}
+
+func (f *uintFieldAdapter) Dump(value interface{}) (string, error) {
+	switch v := reflect.ValueOf(value); v.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	}
+
+	return "", errUnsupportedValue(value)
+}
+
+// Float
+type floatFieldAdapter struct {
+	bitSize int
+}
+
+func (f *floatFieldAdapter) Load(value string) (interface{}, error) {
+	return strconv.ParseFloat(value, f.bitSize)
+}
+
+func (f *floatFieldAdapter) Dump(value interface{}) (string, error) {
+	switch v := reflect.ValueOf(value); v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, f.bitSize), nil
+	}
+
+	return "", errUnsupportedValue(value)
 }
 
 // Datetime
@@ -96,45 +279,129 @@ type datetimeFieldAdapter struct {
 	datetimeFormat string
 }
 
-func (f *datetimeFieldAdapter) Load(value string) interface{} {
-	t, _ := time.Parse(f.datetimeFormat, value)
-	return t
+func (f *datetimeFieldAdapter) Load(value string) (interface{}, error) {
+	return time.Parse(f.datetimeFormat, value)
 }
 
-func (f *datetimeFieldAdapter) Dump(value interface{}) string {
-	return value.(time.Time).Format(f.datetimeFormat)
+func (f *datetimeFieldAdapter) Dump(value interface{}) (string, error) {
+	if t, ok := value.(time.Time); ok {
+		return t.Format(f.datetimeFormat), nil
+	}
+
+	return "", errUnsupportedValue(value)
+}
+
+// Duration
+type durationFieldAdapter struct{}
+
+func (f *durationFieldAdapter) Load(value string) (interface{}, error) {
+	return time.ParseDuration(value)
+}
+
+func (f *durationFieldAdapter) Dump(value interface{}) (string, error) {
+	if d, ok := value.(time.Duration); ok {
+		return d.String(), nil
+	}
+
+	return "", errUnsupportedValue(value)
 }
 
 // JSON
-type jsonFieldAdapter struct{}
+type jsonFieldAdapter struct {
+	typ reflect.Type
+}
 
-func (f *jsonFieldAdapter) Load(value string) interface{} {
-	var v interface{}
+func (f *jsonFieldAdapter) Load(value string) (interface{}, error) {
+	v := reflect.New(f.typ)
 
-	jsoniter.Unmarshal([]byte(value), &v) // nolint: errcheck
+	if err := jsoniter.Unmarshal([]byte(value), v.Interface()); err != nil {
+		return nil, err
+	}
 
-	return v
+	return v.Elem().Interface(), nil
 }
 
-func (f *jsonFieldAdapter) Dump(value interface{}) string {
-	if b, err := jsoniter.Marshal(value); err == nil {
-		return string(b)
+func (f *jsonFieldAdapter) Dump(value interface{}) (string, error) {
+	b, err := jsoniter.Marshal(value)
+	if err != nil {
+		return "", err
 	}
 
-	return ""
+	return string(b), nil
 }
 
-// Bool
+// Bytes
+type bytesFieldAdapter struct{}
+
+func (f *bytesFieldAdapter) Load(value string) (interface{}, error) {
+	return []byte(value), nil
+}
+
+func (f *bytesFieldAdapter) Dump(value interface{}) (string, error) {
+	if v := reflect.ValueOf(value); v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+		return string(v.Bytes()), nil
+	}
+
+	return "", errUnsupportedValue(value)
+}
+
+// Bool, values other than "t" are loaded as false.
 type boolFieldAdapter struct{}
 
-func (f *boolFieldAdapter) Load(value string) interface{} {
-	return value == "t"
+func (f *boolFieldAdapter) Load(value string) (interface{}, error) {
+	return value == "t", nil
+}
+
+func (f *boolFieldAdapter) Dump(value interface{}) (string, error) {
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Bool {
+		return "", errUnsupportedValue(value)
+	}
+
+	if v.Bool() {
+		return "t", nil
+	}
+
+	return "f", nil
+}
+
+// Pointer, nil is stored as an empty value.
+type pointerFieldAdapter struct {
+	typ  reflect.Type
+	elem FieldAdapterE
+}
+
+func (f *pointerFieldAdapter) Load(value string) (interface{}, error) {
+	if !strings.HasPrefix(value, pointerPrefix) {
+		return nil, fmt.Errorf("invalid pointer value %q", value)
+	}
+
+	x, err := f.elem.Load(value[len(pointerPrefix):])
+	if err != nil {
+		return nil, err
+	}
+
+	p := reflect.New(f.typ)
+	p.Elem().Set(convertValue(x, f.typ))
+
+	return p.Interface(), nil
 }
 
-func (f *boolFieldAdapter) Dump(value interface{}) string {
-	if value.(bool) {
-		return "t"
+// Dump dumps pointer or a value it points to.
+func (f *pointerFieldAdapter) Dump(value interface{}) (string, error) {
+	v := reflect.ValueOf(value)
+	if !v.IsValid() || (v.Kind() == reflect.Ptr && v.IsNil()) {
+		return "", nil
+	}
+
+	if v.Kind() == reflect.Ptr && v.Type().Elem() == f.typ {
+		value = v.Elem().Interface()
+	}
+
+	s, err := f.elem.Dump(value)
+	if err != nil {
+		return "", err
 	}
 
-	return "f"
+	return pointerPrefix + s, nil
 }
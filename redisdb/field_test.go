@@ -0,0 +1,194 @@
+package redisdb
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type legacyAdapter struct{ v string }
+
+func (a *legacyAdapter) Load(value string) interface{} {
+	return legacyAdapter{v: value}
+}
+
+func (a *legacyAdapter) Dump(value interface{}) string {
+	return value.(legacyAdapter).v
+}
+
+type adapterStruct struct {
+	A int
+	B string
+}
+
+type adapterModel struct {
+	ID     int
+	Ptr    *int `default:"5"`
+	PtrStr *string
+	Legacy legacyAdapter
+}
+
+func TestFieldAdapterLoad(t *testing.T) {
+	Convey("Given field adapters, Load", t, func() {
+		for _, tc := range []struct {
+			name     string
+			typ      reflect.Type
+			value    string
+			expected interface{}
+		}{
+			{"int8", reflect.TypeOf(int8(0)), "-128", int64(-128)},
+			{"int16", reflect.TypeOf(int16(0)), "32767", int64(32767)},
+			{"int32", reflect.TypeOf(int32(0)), "-2147483648", int64(-2147483648)},
+			{"uint8", reflect.TypeOf(uint8(0)), "255", uint64(255)},
+			{"uint64", reflect.TypeOf(uint64(0)), "18446744073709551615", uint64(18446744073709551615)},
+			{"float32", reflect.TypeOf(float32(0)), "1.5", 1.5},
+			{"duration", durationType, "1h30m", 90 * time.Minute},
+			{"bytes", reflect.TypeOf([]byte(nil)), "a\x00b", []byte("a\x00b")},
+			{"bool true", reflect.TypeOf(false), "t", true},
+			{"bool false", reflect.TypeOf(false), "f", false},
+			{"bool other value as false", reflect.TypeOf(false), "x", false},
+			{"int slice", reflect.TypeOf([]int(nil)), "[1,2]", []int{1, 2}},
+			{"map", reflect.TypeOf(map[string]int(nil)), `{"a":1}`, map[string]int{"a": 1}},
+			{"struct", reflect.TypeOf(adapterStruct{}), `{"A":1,"B":"b"}`, adapterStruct{A: 1, B: "b"}},
+		} {
+			tc := tc
+
+			Convey("loads "+tc.name, func() {
+				x, err := getAdapter(tc.typ).Load(tc.value)
+				So(err, ShouldBeNil)
+				So(x, ShouldResemble, tc.expected)
+			})
+		}
+
+		for _, tc := range []struct {
+			name  string
+			typ   reflect.Type
+			value string
+		}{
+			{"int8 overflow", reflect.TypeOf(int8(0)), "128"},
+			{"int16 overflow", reflect.TypeOf(int16(0)), "-32769"},
+			{"int32 overflow", reflect.TypeOf(int32(0)), "2147483648"},
+			{"uint8 overflow", reflect.TypeOf(uint8(0)), "256"},
+			{"negative uint", reflect.TypeOf(uint(0)), "-1"},
+			{"float32 overflow", reflect.TypeOf(float32(0)), "3.5e38"},
+			{"invalid int", reflect.TypeOf(0), "abc"},
+			{"invalid duration", durationType, "1x"},
+			{"invalid json", reflect.TypeOf([]int(nil)), `["a"]`},
+			{"pointer without prefix", reflect.TypeOf((*int)(nil)), "0"},
+			{"pointer to invalid value", reflect.TypeOf((*int)(nil)), "=abc"},
+		} {
+			tc := tc
+
+			Convey("rejects "+tc.name, func() {
+				_, err := getAdapter(tc.typ).Load(tc.value)
+				So(err, ShouldNotBeNil)
+			})
+		}
+	})
+}
+
+func TestFieldAdapterDump(t *testing.T) {
+	zero := 0
+	empty := ""
+
+	Convey("Given field adapters, Dump", t, func() {
+		for _, tc := range []struct {
+			name     string
+			typ      reflect.Type
+			value    interface{}
+			expected string
+		}{
+			{"int8", reflect.TypeOf(int8(0)), int8(-128), "-128"},
+			{"uint16", reflect.TypeOf(uint16(0)), uint16(65535), "65535"},
+			{"float32", reflect.TypeOf(float32(0)), float32(0.1), "0.1"},
+			{"duration", durationType, 90 * time.Minute, "1h30m0s"},
+			{"bytes", reflect.TypeOf([]byte(nil)), []byte("a\x00b"), "a\x00b"},
+			{"bool", reflect.TypeOf(false), true, "t"},
+			{"int slice", reflect.TypeOf([]int(nil)), []int{1, 2}, "[1,2]"},
+			{"map", reflect.TypeOf(map[string]int(nil)), map[string]int{"a": 1}, `{"a":1}`},
+			{"struct", reflect.TypeOf(adapterStruct{}), adapterStruct{A: 1, B: "b"}, `{"A":1,"B":"b"}`},
+			{"nil pointer", reflect.TypeOf((*int)(nil)), (*int)(nil), ""},
+			{"pointer to zero", reflect.TypeOf((*int)(nil)), &zero, "=0"},
+			{"pointer to empty string", reflect.TypeOf((*string)(nil)), &empty, "="},
+		} {
+			tc := tc
+
+			Convey("dumps "+tc.name, func() {
+				s, err := getAdapter(tc.typ).Dump(tc.value)
+				So(err, ShouldBeNil)
+				So(s, ShouldEqual, tc.expected)
+			})
+		}
+
+		for _, tc := range []struct {
+			name  string
+			typ   reflect.Type
+			value interface{}
+		}{
+			{"string as int", reflect.TypeOf(0), "1"},
+			{"int as uint", reflect.TypeOf(uint(0)), 1},
+			{"int as float", reflect.TypeOf(0.0), 1},
+			{"int as bool", reflect.TypeOf(false), 1},
+			{"string as duration", durationType, "1h"},
+			{"string as bytes", reflect.TypeOf([]byte(nil)), "a"},
+		} {
+			tc := tc
+
+			Convey("rejects "+tc.name, func() {
+				_, err := getAdapter(tc.typ).Dump(tc.value)
+				So(err, ShouldNotBeNil)
+			})
+		}
+	})
+}
+
+func TestField(t *testing.T) {
+	Convey("Given table with pointer and legacy adapter fields", t, func() {
+		table := GetTable(reflect.TypeOf(adapterModel{}))
+
+		Convey("pointer field round-trips nil and pointer to empty value", func() {
+			f := table.Fields["ptr_str"]
+
+			s, err := f.dump((*string)(nil))
+			So(err, ShouldBeNil)
+			So(s, ShouldEqual, "")
+
+			v, err := f.load("=")
+			So(err, ShouldBeNil)
+			So(v.IsNil(), ShouldBeFalse)
+			So(v.Elem().String(), ShouldEqual, "")
+		})
+		Convey("default of pointer field is a pointer to its value", func() {
+			v := table.Fields["ptr"].Default()
+			So(v.IsNil(), ShouldBeFalse)
+			So(v.Elem().Int(), ShouldEqual, 5)
+		})
+		Convey("invalid value is reported as ErrInvalidValue", func() {
+			_, err := table.Fields["ptr"].load(strconv.Itoa(1))
+			So(errors.Is(err, ErrInvalidValue), ShouldBeTrue)
+		})
+		Convey("legacy FieldAdapter is wrapped", func() {
+			f := table.Fields["legacy"]
+
+			v, err := f.load("abc")
+			So(err, ShouldBeNil)
+			So(v.Interface(), ShouldResemble, legacyAdapter{v: "abc"})
+
+			s, err := f.dump(legacyAdapter{v: "abc"})
+			So(err, ShouldBeNil)
+			So(s, ShouldEqual, "abc")
+			So(f.Adapter, ShouldHaveSameTypeAs, &legacyAdapter{})
+		})
+		Convey("Adapter ignores errors of AdapterE", func() {
+			f := table.Fields["ptr"]
+
+			So(f.Adapter.Load("abc"), ShouldBeNil)
+			So(*f.Adapter.Load("=3").(*int), ShouldEqual, 3)
+			So(f.Adapter.Dump("abc"), ShouldEqual, "")
+		})
+	})
+}
@@ -0,0 +1,213 @@
+package redisdb
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+	"github.com/pkg/errors"
+
+	"github.com/Syncano/pkg-go/v2/util"
+)
+
+// ErrNotIndexed marks that FindBy or ListBy was called with a field that is not indexed.
+var ErrNotIndexed = errors.New("redis: field is not indexed")
+
+const findByBatchSize = 10
+
+// getIndexKey returns key of sorted set holding keys of objects with field equal to value, scored by pk.
+func (c *DBCtx) getIndexKey(field, value string) string {
+	return fmt.Sprintf("%s:idx:%s:%s", c.model.Key(c.args), field, value)
+}
+
+// getFieldIndexKey returns index key of indexed field equal to value.
+func (c *DBCtx) getFieldIndexKey(field string, value interface{}) (string, error) {
+	f, ok := c.table.Fields[field]
+	if !ok || !f.Indexed {
+		return "", ErrNotIndexed
+	}
+
+	s, err := f.dump(value)
+	if err != nil {
+		return "", err
+	}
+
+	return c.getIndexKey(field, s), nil
+}
+
+// indexedFields returns indexed fields out of given ones.
+func (c *DBCtx) indexedFields(fields []string) []string {
+	var indexed []string
+
+	for _, f := range fields {
+		if field, ok := c.table.Fields[f]; ok && field.Indexed {
+			indexed = append(indexed, f)
+		}
+	}
+
+	return indexed
+}
+
+// index adds object to indexes of given dumped field values.
+func (c *DBCtx) index(pipe redis.Pipeliner, pk int, objectKey string, values map[string]string, ttl time.Duration) {
+	for f, v := range values {
+		if v == "" {
+			continue
+		}
+
+		indexKey := c.getIndexKey(f, v)
+		pipe.ZAdd(indexKey, &redis.Z{Score: float64(pk), Member: objectKey})
+
+		if ttl > 0 {
+			pipe.Expire(indexKey, ttl)
+		}
+	}
+}
+
+// unindex removes object from indexes of given dumped field values.
+func (c *DBCtx) unindex(pipe redis.Pipeliner, objectKey string, values map[string]string) {
+	for f, v := range values {
+		if v != "" {
+			pipe.ZRem(c.getIndexKey(f, v), objectKey)
+		}
+	}
+}
+
+// watchIndexed runs fn in a transaction watching object along with current values of its indexed fields.
+// It is retried if object is modified concurrently.
+func (c *DBCtx) watchIndexed(cli *redis.Client, objectKey string, fields []string,
+	fn func(tx *redis.Tx, old map[string]string) error) error {
+	_, err := util.RetryWithCritical(updateRetries, 0, func() (bool, error) {
+		err := cli.Watch(func(tx *redis.Tx) error {
+			old, err := readIndexed(tx, objectKey, fields)
+			if err != nil {
+				return err
+			}
+
+			return fn(tx, old)
+		}, objectKey)
+
+		if err == redis.TxFailedErr {
+			return false, err
+		}
+
+		return true, err
+	})
+
+	return err
+}
+
+func readIndexed(tx *redis.Tx, objectKey string, fields []string) (map[string]string, error) {
+	if len(fields) == 0 {
+		return nil, nil
+	}
+
+	vals, err := tx.HMGet(objectKey, fields...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	return indexedValues(fields, vals), nil
+}
+
+// indexedValues returns map of given fields to their values set in vals.
+func indexedValues(fields []string, vals []interface{}) map[string]string {
+	m := make(map[string]string, len(fields))
+
+	for i, f := range fields {
+		if s, ok := vals[i].(string); ok {
+			m[f] = s
+		}
+	}
+
+	return m
+}
+
+// FindBy selects object with the lowest pk whose indexed field is equal to value.
+func (c *DBCtx) FindBy(field string, value interface{}) error {
+	return c.FindByContext(context.Background(), field, value)
+}
+
+// FindByContext selects object by indexed field using given context, see FindBy.
+func (c *DBCtx) FindByContext(ctx context.Context, field string, value interface{}) (err error) {
+	if err := c.check(reflect.Struct); err != nil {
+		return err
+	}
+
+	indexKey, err := c.getFieldIndexKey(field, value)
+	if err != nil {
+		return err
+	}
+
+	cli, span := c.startSpan(ctx, "FindBy")
+	defer func() { endSpan(span, err) }()
+
+	var (
+		keys []string
+		r    map[string]string
+	)
+
+	// Skip objects that have already expired, see PruneBy.
+	for start := int64(0); ; start += findByBatchSize {
+		keys, err = cli.ZRange(indexKey, start, start+findByBatchSize-1).Result()
+		if err != nil {
+			return err
+		}
+
+		for _, key := range keys {
+			if r, err = cli.HGetAll(key).Result(); err != nil {
+				return err
+			}
+
+			if len(r) > 0 {
+				return c.loadObject(r)
+			}
+		}
+
+		if len(keys) < findByBatchSize {
+			return ErrNotFound
+		}
+	}
+}
+
+// ListBy selects a list of objects whose indexed field is equal to value, see List.
+func (c *DBCtx) ListBy(field string, value interface{}, minPK, maxPK, limit int, isOrderAsc bool,
+	skippedFields []string) error {
+	return c.ListByContext(context.Background(), field, value, minPK, maxPK, limit, isOrderAsc, skippedFields)
+}
+
+// ListByContext selects a list of objects by indexed field using given context, see ListBy.
+func (c *DBCtx) ListByContext(ctx context.Context, field string, value interface{}, minPK, maxPK, limit int,
+	isOrderAsc bool, skippedFields []string) error {
+	if err := c.check(reflect.Slice); err != nil {
+		return err
+	}
+
+	indexKey, err := c.getFieldIndexKey(field, value)
+	if err != nil {
+		return err
+	}
+
+	return c.list(ctx, "ListBy", indexKey, minPK, maxPK, limit, isOrderAsc, skippedFields)
+}
+
+// PruneBy removes members of index of field equal to value whose objects are gone, see Prune.
+func (c *DBCtx) PruneBy(field string, value interface{}) (int, error) {
+	return c.PruneByContext(context.Background(), field, value)
+}
+
+// PruneByContext removes members of index whose objects are gone using given context, see PruneBy.
+func (c *DBCtx) PruneByContext(ctx context.Context, field string, value interface{}) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+
+	indexKey, err := c.getFieldIndexKey(field, value)
+	if err != nil {
+		return 0, err
+	}
+
+	return c.prune(ctx, "PruneBy", indexKey)
+}
@@ -0,0 +1,185 @@
+package redisdb
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type trimModel struct {
+	ID    int
+	Owner string `redis:"owner,index"`
+}
+
+func (m *trimModel) Key(args map[string]interface{}) string               { return "trim" }
+func (m *trimModel) ListArgs(args map[string]interface{}) string          { return "" }
+func (m *trimModel) ListMaxSize(args map[string]interface{}) int          { return 2 }
+func (m *trimModel) TTL(args map[string]interface{}) time.Duration        { return 0 }
+func (m *trimModel) TrimmedTTL(args map[string]interface{}) time.Duration { return time.Hour }
+
+type plainModel struct {
+	ID   int
+	Name string
+}
+
+func (m *plainModel) Key(args map[string]interface{}) string               { return "plain" }
+func (m *plainModel) ListArgs(args map[string]interface{}) string          { return "" }
+func (m *plainModel) ListMaxSize(args map[string]interface{}) int          { return 100 }
+func (m *plainModel) TTL(args map[string]interface{}) time.Duration        { return 0 }
+func (m *plainModel) TrimmedTTL(args map[string]interface{}) time.Duration { return 0 }
+
+func TestIndex(t *testing.T) {
+	Convey("Given DB with saved objects", t, func() {
+		db, mr := newTestDB()
+		objs := []*testModel{{Name: "a", Owner: "x"}, {Name: "b", Owner: "y"}, {Name: "c", Owner: "x"}}
+
+		for _, o := range objs {
+			So(db.Model(o, nil).Save(nil), ShouldBeNil)
+		}
+
+		indexed := func(owner string) []string {
+			members, _ := mr.ZMembers("test:idx:owner:" + owner)
+			return members
+		}
+		findBy := func(owner string) (*testModel, error) {
+			o := &testModel{}
+			err := db.Model(o, nil).FindBy("owner", owner)
+
+			return o, err
+		}
+		listBy := func(owner string) []*testModel {
+			var l []*testModel
+			So(db.Model(&l, nil).ListBy("owner", owner, 0, 0, 10, true, nil), ShouldBeNil)
+
+			return l
+		}
+
+		Convey("Save indexes objects", func() {
+			So(indexed("x"), ShouldResemble, []string{"test:1", "test:3"})
+			So(indexed("y"), ShouldResemble, []string{"test:2"})
+		})
+		Convey("Save moves object between indexes", func() {
+			objs[0].Owner = "y"
+			So(db.Model(objs[0], nil).Save(nil), ShouldBeNil)
+			So(indexed("x"), ShouldResemble, []string{"test:3"})
+			So(indexed("y"), ShouldResemble, []string{"test:1", "test:2"})
+
+			objs[0].Owner = ""
+			So(db.Model(objs[0], nil).Save([]string{"owner"}), ShouldBeNil)
+			So(indexed("y"), ShouldResemble, []string{"test:2"})
+		})
+		Convey("Update moves object between indexes", func() {
+			So(db.Model(&testModel{}, nil).Update(1, map[string]interface{}{"owner": "z"}, nil), ShouldBeNil)
+			So(indexed("x"), ShouldResemble, []string{"test:3"})
+			So(indexed("z"), ShouldResemble, []string{"test:1"})
+		})
+		Convey("Update of missing object neither creates it nor indexes it", func() {
+			So(db.Model(&testModel{}, nil).Update(10, map[string]interface{}{"owner": "z"}, nil), ShouldEqual, ErrNotFound)
+			So(mr.Exists("test:10"), ShouldBeFalse)
+			So(mr.Exists("test:idx:owner:z"), ShouldBeFalse)
+		})
+		Convey("Update of missing object without indexed fields sets its fields", func() {
+			So(db.Model(&plainModel{}, nil).Update(10, map[string]interface{}{"name": "z"}, nil), ShouldBeNil)
+			So(mr.HGet("plain:10", "name"), ShouldEqual, "z")
+		})
+		Convey("Delete removes object from index", func() {
+			So(db.Model(objs[0], nil).Delete(), ShouldBeNil)
+			So(indexed("x"), ShouldResemble, []string{"test:3"})
+		})
+		Convey("FindBy selects object with the lowest pk", func() {
+			o, err := findBy("x")
+			So(err, ShouldBeNil)
+			So(o, ShouldResemble, objs[0])
+
+			_, err = findBy("z")
+			So(err, ShouldEqual, ErrNotFound)
+			So(db.Model(&testModel{}, nil).FindBy("name", "a"), ShouldEqual, ErrNotIndexed)
+		})
+		Convey("FindBy skips expired objects leaving them in index", func() {
+			mr.Del("test:1")
+
+			o, err := findBy("x")
+			So(err, ShouldBeNil)
+			So(o, ShouldResemble, objs[2])
+			So(indexed("x"), ShouldResemble, []string{"test:1", "test:3"})
+
+			mr.Del("test:3")
+
+			_, err = findBy("x")
+			So(err, ShouldEqual, ErrNotFound)
+		})
+		Convey("FindBy skips expired objects spanning many batches", func() {
+			for i := 0; i < 2*findByBatchSize; i++ {
+				o := &testModel{Owner: "z"}
+				So(db.Model(o, nil).Save(nil), ShouldBeNil)
+				mr.Del(fmt.Sprintf("test:%d", o.ID))
+			}
+
+			last := &testModel{Owner: "z"}
+			So(db.Model(last, nil).Save(nil), ShouldBeNil)
+
+			o, err := findBy("z")
+			So(err, ShouldBeNil)
+			So(o, ShouldResemble, last)
+			So(indexed("z"), ShouldHaveLength, 2*findByBatchSize+1)
+		})
+		Convey("ListBy selects objects by index", func() {
+			So(listBy("x"), ShouldResemble, []*testModel{objs[0], objs[2]})
+			So(listBy("z"), ShouldBeEmpty)
+		})
+		Convey("ListBy skips expired objects leaving them in index", func() {
+			mr.Del("test:1")
+			So(listBy("x"), ShouldResemble, []*testModel{objs[2]})
+			So(indexed("x"), ShouldResemble, []string{"test:1", "test:3"})
+		})
+		Convey("List skips expired objects leaving them in list", func() {
+			mr.Del("test:2")
+
+			var l []*testModel
+			So(db.Model(&l, nil).List(0, 0, 10, true, nil), ShouldBeNil)
+			So(l, ShouldResemble, []*testModel{objs[0], objs[2]})
+
+			members, _ := mr.ZMembers("test:set:")
+			So(members, ShouldResemble, []string{"test:1", "test:2", "test:3"})
+		})
+		Convey("Prune removes expired objects from list", func() {
+			mr.Del("test:2")
+
+			n, err := db.Model(&testModel{}, nil).Prune()
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 1)
+
+			members, _ := mr.ZMembers("test:set:")
+			So(members, ShouldResemble, []string{"test:1", "test:3"})
+		})
+		Convey("PruneBy removes expired objects spanning many batches from index", func() {
+			for i := 0; i < 2*pruneBatchSize; i++ {
+				_, err := mr.ZAdd("test:idx:owner:x", float64(i+2), fmt.Sprintf("test:gone%d", i))
+				So(err, ShouldBeNil)
+			}
+
+			n, err := db.Model(&testModel{}, nil).PruneBy("owner", "x")
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 2*pruneBatchSize)
+			So(indexed("x"), ShouldResemble, []string{"test:1", "test:3"})
+
+			_, err = db.Model(&testModel{}, nil).PruneBy("name", "a")
+			So(err, ShouldEqual, ErrNotIndexed)
+		})
+		Convey("objects trimmed from list are removed from index", func() {
+			for _, owner := range []string{"x", "x", "x"} {
+				So(db.Model(&trimModel{Owner: owner}, nil).Save(nil), ShouldBeNil)
+			}
+
+			members, _ := mr.ZMembers("trim:set:")
+			So(members, ShouldResemble, []string{"trim:2", "trim:3"})
+			members, _ = mr.ZMembers("trim:idx:owner:x")
+			So(members, ShouldResemble, []string{"trim:2", "trim:3"})
+			So(mr.TTL("trim:1"), ShouldBeGreaterThan, 0)
+		})
+
+		mr.Close()
+	})
+}
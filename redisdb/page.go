@@ -0,0 +1,203 @@
+package redisdb
+
+import (
+	"context"
+	"encoding/base64"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/go-redis/redis/v7"
+	"github.com/pkg/errors"
+)
+
+// ErrInvalidCursor marks that Page was called with a cursor it did not return or with a different order.
+var ErrInvalidCursor = errors.New("redis: invalid cursor")
+
+// PageMaxLimit is the maximum number of objects selected by Page, larger limits are lowered to it.
+const PageMaxLimit = 1000
+
+// cursorDir returns prefix of cursors of pages in given order.
+func cursorDir(isOrderAsc bool) string {
+	if isOrderAsc {
+		return "a:"
+	}
+
+	return "d:"
+}
+
+// encodeCursor returns opaque cursor of a page starting after pk in given order.
+func encodeCursor(pk int, isOrderAsc bool) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(cursorDir(isOrderAsc) + strconv.Itoa(pk)))
+}
+
+// decodeCursor returns pk encoded in cursor. Cursor returned for a different order is rejected.
+func decodeCursor(cursor string, isOrderAsc bool) (int, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+
+	v, ok := strings.CutPrefix(string(b), cursorDir(isOrderAsc))
+	if !ok {
+		return 0, ErrInvalidCursor
+	}
+
+	pk, err := strconv.Atoi(v)
+	if err != nil || pk <= 0 {
+		return 0, ErrInvalidCursor
+	}
+
+	return pk, nil
+}
+
+// Page selects up to limit objects ordered by pk that come after given cursor. Empty cursor selects the first page.
+// Returns cursor of the next page or empty string if there are no more objects. Cursor is only valid for the same
+// order. Unlike List, page is capped by PageMaxLimit instead of ListMaxSize and list members of objects that have
+// already expired are skipped, see Prune.
+func (c *DBCtx) Page(cursor string, limit int, isOrderAsc bool, skippedFields []string) (string, error) {
+	return c.PageContext(context.Background(), cursor, limit, isOrderAsc, skippedFields)
+}
+
+// PageContext selects a page of objects using given context, see Page.
+func (c *DBCtx) PageContext(ctx context.Context, cursor string, limit int, isOrderAsc bool,
+	skippedFields []string) (string, error) {
+	if err := c.check(reflect.Slice); err != nil {
+		return "", err
+	}
+
+	return c.page(ctx, "Page", c.getListKey(), cursor, limit, isOrderAsc, skippedFields)
+}
+
+// PageBy selects a page of objects whose indexed field is equal to value, see Page.
+func (c *DBCtx) PageBy(field string, value interface{}, cursor string, limit int, isOrderAsc bool,
+	skippedFields []string) (string, error) {
+	return c.PageByContext(context.Background(), field, value, cursor, limit, isOrderAsc, skippedFields)
+}
+
+// PageByContext selects a page of objects by indexed field using given context, see PageBy.
+func (c *DBCtx) PageByContext(ctx context.Context, field string, value interface{}, cursor string, limit int,
+	isOrderAsc bool, skippedFields []string) (string, error) {
+	if err := c.check(reflect.Slice); err != nil {
+		return "", err
+	}
+
+	indexKey, err := c.getFieldIndexKey(field, value)
+	if err != nil {
+		return "", err
+	}
+
+	return c.page(ctx, "PageBy", indexKey, cursor, limit, isOrderAsc, skippedFields)
+}
+
+// page selects a page of objects from sorted set at listKey.
+func (c *DBCtx) page(ctx context.Context, op, listKey, cursor string, limit int, isOrderAsc bool,
+	skippedFields []string) (next string, err error) {
+	after, err := decodeCursor(cursor, isOrderAsc)
+	if err != nil {
+		return "", err
+	}
+
+	if limit <= 0 {
+		c.createSlice(nil)
+		return "", nil
+	}
+
+	if limit > PageMaxLimit {
+		limit = PageMaxLimit
+	}
+
+	cli, span := c.startSpan(ctx, op)
+	defer func() { endSpan(span, err) }()
+
+	fields := c.getFetchedFields(skippedFields)
+
+	var objs []reflect.Value
+
+	// Each batch starts after the last member of previous one, so loop ends once sorted set is exhausted even if
+	// its members are removed concurrently.
+	for len(objs) < limit {
+		count := limit - len(objs)
+
+		zs, err := c.rangeAfter(cli, listKey, after, count, isOrderAsc)
+		if err != nil {
+			return "", err
+		}
+
+		if len(zs) == 0 {
+			after = 0
+			break
+		}
+
+		keys := make([]string, len(zs))
+		for i, z := range zs {
+			keys[i] = z.Member.(string)
+		}
+
+		loaded, err := c.fetchObjects(cli, keys, fields)
+		if err != nil {
+			return "", err
+		}
+
+		objs = append(objs, loaded...)
+		after = int(zs[len(zs)-1].Score)
+
+		if len(zs) < count {
+			after = 0
+			break
+		}
+	}
+
+	// Page that was filled up to limit is the last one if nothing follows it.
+	if after != 0 {
+		zs, err := c.rangeAfter(cli, listKey, after, 1, isOrderAsc)
+		if err != nil {
+			return "", err
+		}
+
+		if len(zs) == 0 {
+			after = 0
+		}
+	}
+
+	c.createSlice(objs)
+
+	if after != 0 {
+		next = encodeCursor(after, isOrderAsc)
+	}
+
+	return next, nil
+}
+
+// rangeAfter returns up to count members of sorted set at listKey with pk after given one.
+func (c *DBCtx) rangeAfter(cli *redis.Client, listKey string, after, count int, isOrderAsc bool) ([]redis.Z, error) {
+	opt := &redis.ZRangeBy{Min: "-inf", Max: "+inf", Count: int64(count)}
+
+	if isOrderAsc {
+		if after > 0 {
+			opt.Min = "(" + strconv.Itoa(after)
+		}
+
+		return cli.ZRangeByScoreWithScores(listKey, opt).Result()
+	}
+
+	if after > 0 {
+		opt.Max = "(" + strconv.Itoa(after)
+	}
+
+	return cli.ZRevRangeByScoreWithScores(listKey, opt).Result()
+}
+
+func containsString(s []string, v string) bool {
+	for _, cur := range s {
+		if cur == v {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,116 @@
+package redisdb
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestPage(t *testing.T) {
+	Convey("Given DB with saved objects", t, func() {
+		db, mr := newTestDB()
+
+		var objs []*testModel
+
+		for i := 0; i < 5; i++ {
+			o := &testModel{Name: fmt.Sprint(i), Owner: "x"}
+			So(db.Model(o, nil).Save(nil), ShouldBeNil)
+			objs = append(objs, o)
+		}
+
+		page := func(cursor string, limit int, isOrderAsc bool) ([]*testModel, string) {
+			var l []*testModel
+
+			next, err := db.Model(&l, nil).Page(cursor, limit, isOrderAsc, nil)
+			So(err, ShouldBeNil)
+
+			return l, next
+		}
+
+		Convey("empty cursor selects the first page", func() {
+			l, next := page("", 2, true)
+			So(l, ShouldResemble, objs[:2])
+			So(next, ShouldNotBeEmpty)
+
+			l, next = page(next, 2, true)
+			So(l, ShouldResemble, objs[2:4])
+
+			l, next = page(next, 2, true)
+			So(l, ShouldResemble, objs[4:])
+			So(next, ShouldBeEmpty)
+		})
+		Convey("descending pages are in reverse order", func() {
+			l, next := page("", 3, false)
+			So(l, ShouldResemble, []*testModel{objs[4], objs[3], objs[2]})
+
+			l, next = page(next, 3, false)
+			So(l, ShouldResemble, []*testModel{objs[1], objs[0]})
+			So(next, ShouldBeEmpty)
+		})
+		Convey("last page filled up to limit returns empty cursor", func() {
+			l, next := page("", 5, true)
+			So(l, ShouldResemble, objs)
+			So(next, ShouldBeEmpty)
+		})
+		Convey("invalid cursor is rejected", func() {
+			var l []*testModel
+
+			for _, cursor := range []string{"!", encodeCursor(0, true), encodeCursor(1, true)[1:], "YWJj", "MQ"} {
+				_, err := db.Model(&l, nil).Page(cursor, 2, true, nil)
+				So(err, ShouldEqual, ErrInvalidCursor)
+			}
+		})
+		Convey("cursor of ascending page is rejected for descending one", func() {
+			_, next := page("", 2, true)
+
+			var l []*testModel
+
+			_, err := db.Model(&l, nil).Page(next, 2, false, nil)
+			So(err, ShouldEqual, ErrInvalidCursor)
+		})
+		Convey("limit is capped by PageMaxLimit", func() {
+			// Objects are added directly as saving trims list to ListMaxSize.
+			for i := 6; i <= PageMaxLimit+5; i++ {
+				key := fmt.Sprintf("test:%d", i)
+				mr.HSet(key, "id", fmt.Sprint(i))
+				_, err := mr.ZAdd("test:set:", float64(i), key)
+				So(err, ShouldBeNil)
+			}
+
+			l, next := page("", PageMaxLimit+10, true)
+			So(l, ShouldHaveLength, PageMaxLimit)
+			So(next, ShouldNotBeEmpty)
+		})
+		Convey("expired objects are skipped and page is still filled up to limit", func() {
+			mr.Del("test:2")
+			mr.Del("test:3")
+
+			l, next := page("", 3, true)
+			So(l, ShouldResemble, []*testModel{objs[0], objs[3], objs[4]})
+			So(next, ShouldBeEmpty)
+
+			members, _ := mr.ZMembers("test:set:")
+			So(members, ShouldHaveLength, 5)
+		})
+		Convey("limit larger than the list ends when all objects expired", func() {
+			for i := 1; i <= 5; i++ {
+				mr.Del(fmt.Sprintf("test:%d", i))
+			}
+
+			l, next := page("", 100, true)
+			So(l, ShouldBeEmpty)
+			So(next, ShouldBeEmpty)
+		})
+		Convey("PageBy selects a page of objects by index", func() {
+			var l []*testModel
+
+			next, err := db.Model(&l, nil).PageBy("owner", "x", "", 4, true, nil)
+			So(err, ShouldBeNil)
+			So(l, ShouldResemble, objs[:4])
+			So(next, ShouldNotBeEmpty)
+		})
+
+		mr.Close()
+	})
+}
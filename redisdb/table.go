@@ -3,6 +3,8 @@ package redisdb
 import (
 	"fmt"
 	"reflect"
+	"sort"
+	"strings"
 	"sync"
 
 	"github.com/Syncano/pkg-go/v2/util"
@@ -26,18 +28,37 @@ type Table struct {
 	pkField *Field
 
 	Fields map[string]*Field
+	// Indexes holds names of indexed fields.
+	Indexes []string
 }
 
-func newTable(typ reflect.Type) *Table {
+func newTable(typ reflect.Type) (*Table, error) {
 	r := &Table{
 		Type:   typ,
 		Name:   typ.Name(),
 		Fields: make(map[string]*Field, typ.NumField()),
 	}
 
-	r.addFields(r.Type, nil)
+	if err := r.addFields(r.Type, nil); err != nil {
+		return nil, err
+	}
+
+	pk, ok := r.Fields[pkName]
+	if !ok || pk.Field.Type.Kind() != reflect.Int {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPK, typ)
+	}
 
-	return r
+	r.pkField = pk
+
+	for name, f := range r.Fields {
+		if f.Indexed {
+			r.Indexes = append(r.Indexes, name)
+		}
+	}
+
+	sort.Strings(r.Indexes)
+
+	return r, nil
 }
 
 // PK returns PK of table.
@@ -50,7 +71,7 @@ func (r *Table) SetPK(v reflect.Value, pk int) {
 	r.pkField.Value(v).SetInt(int64(pk))
 }
 
-func (r *Table) addFields(typ reflect.Type, baseIndex []int) {
+func (r *Table) addFields(typ reflect.Type, baseIndex []int) error {
 	for i := 0; i < typ.NumField(); i++ {
 		f := typ.Field(i)
 
@@ -64,49 +85,85 @@ func (r *Table) addFields(typ reflect.Type, baseIndex []int) {
 			}
 
 			fieldType := indirectType(f.Type)
-			r.addFields(fieldType, append(index, f.Index...))
+			if err := r.addFields(fieldType, append(index, f.Index...)); err != nil {
+				return err
+			}
 
 			continue
 		}
 
-		field := r.newField(typ, &f)
+		field, err := r.newField(typ, &f)
+		if err != nil {
+			return err
+		}
+
 		if field != nil {
 			r.Fields[field.Name] = field
 		}
 	}
 
-	if _, ok := r.Fields[pkName]; !ok || r.Fields[pkName].Field.Type.Kind() != reflect.Int {
-		panic("redis: pk undefined on model or wrong type (int required)")
-	}
-
-	r.pkField = r.Fields[pkName]
+	return nil
 }
 
-func (r *Table) newField(typ reflect.Type, f *reflect.StructField) *Field {
-	name := f.Name
+func (r *Table) newField(typ reflect.Type, f *reflect.StructField) (*Field, error) {
+	// Skip unexported fields.
+	if f.PkgPath != "" {
+		return nil, nil
+	}
+
+	var (
+		name    string
+		indexed bool
+	)
 
 	if tag := f.Tag.Get("redis"); tag != "" {
 		if tag == "-" {
-			return nil
+			return nil, nil
+		}
+
+		opts := strings.Split(tag, ",")
+		name = opts[0]
+
+		for _, opt := range opts[1:] {
+			if opt == "index" {
+				indexed = true
+			}
 		}
+	}
 
-		name = tag
-	} else {
-		name = util.Underscore(name)
+	if name == "" {
+		name = util.Underscore(f.Name)
 	}
 
-	adapter := getAdapter(f)
+	adapter := getAdapter(f.Type)
 	if adapter == nil {
-		return nil
+		return nil, nil
+	}
+
+	def := f.Tag.Get("default")
+
+	// Default of pointer field is given as value it points to.
+	if _, ok := adapter.(*pointerFieldAdapter); ok && def != "" {
+		def = pointerPrefix + def
 	}
 
-	return &Field{
-		Name:    name,
-		Field:   *f,
-		Type:    typ,
-		Adapter: adapter,
-		def:     f.Tag.Get("default"),
+	field := &Field{
+		Name:     name,
+		Field:    *f,
+		Type:     typ,
+		Adapter:  toFieldAdapter(adapter),
+		AdapterE: adapter,
+		Indexed:  indexed,
+		def:      def,
 	}
+
+	if field.HasDefault() {
+		if _, err := field.load(field.def); err != nil {
+			return nil, err
+		}
+	}
+
+	return field, nil
 }
 
 var _tables = &tables{
@@ -118,10 +175,20 @@ type tables struct {
 	tables map[reflect.Type]*Table
 }
 
-// GetTable returns table for specified type.
+// GetTable returns table for specified struct type. It panics if type is not a valid model, see GetTableE.
 func GetTable(typ reflect.Type) *Table {
+	table, err := GetTableE(typ)
+	if err != nil {
+		panic(err)
+	}
+
+	return table
+}
+
+// GetTableE returns table for specified struct type or an error if type is not a valid model.
+func GetTableE(typ reflect.Type) (*Table, error) {
 	if typ.Kind() != reflect.Struct {
-		panic(fmt.Sprintf("redis: got %s, wanted %s", typ.Kind(), reflect.Struct))
+		return nil, fmt.Errorf("%w: got %s, wanted %s", ErrInvalidModel, typ.Kind(), reflect.Struct)
 	}
 
 	_tables.mu.RLock()
@@ -129,20 +196,22 @@ func GetTable(typ reflect.Type) *Table {
 	_tables.mu.RUnlock()
 
 	if ok {
-		return table
+		return table, nil
 	}
 
 	_tables.mu.Lock()
-	table, ok = _tables.tables[typ]
+	defer _tables.mu.Unlock()
 
-	if ok {
-		_tables.mu.Unlock()
-		return table
+	if table, ok = _tables.tables[typ]; ok {
+		return table, nil
+	}
+
+	table, err := newTable(typ)
+	if err != nil {
+		return nil, err
 	}
 
-	table = newTable(typ)
 	_tables.tables[typ] = table
-	_tables.mu.Unlock()
 
-	return table
+	return table, nil
 }
@@ -0,0 +1,29 @@
+package util
+
+import (
+	"context"
+	"sync"
+
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+// ViewsRegistry registers OpenCensus views when they are first needed, e.g. when metrics are enabled.
+type ViewsRegistry struct {
+	once sync.Once
+}
+
+// Register registers views on its first call only. Panics if views cannot be registered.
+func (r *ViewsRegistry) Register(views ...*view.View) {
+	r.once.Do(func() {
+		Must(view.Register(views...))
+	})
+}
+
+// TagContext returns background context tagged with given key and value, used to record measurements.
+func TagContext(key tag.Key, value string) context.Context {
+	ctx, err := tag.New(context.Background(), tag.Upsert(key, value))
+	Must(err)
+
+	return ctx
+}
@@ -12,6 +12,9 @@ import (
 	json "github.com/json-iterator/go"
 	. "github.com/smartystreets/goconvey/convey"
 	"github.com/stretchr/testify/mock"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
 	"google.golang.org/grpc/peer"
 )
 
@@ -169,3 +172,22 @@ func TestToQuoteJSON(t *testing.T) {
 		})
 	})
 }
+
+func TestMetrics(t *testing.T) {
+	Convey("ViewsRegistry registers views only once", t, func() {
+		var r ViewsRegistry
+
+		v := &view.View{Name: "util_test", Measure: stats.Int64("util_test", "", stats.UnitDimensionless),
+			Aggregation: view.Count()}
+
+		So(func() { r.Register(v) }, ShouldNotPanic)
+		So(func() { r.Register(v, nil) }, ShouldNotPanic)
+		view.Unregister(v)
+	})
+	Convey("TagContext returns context tagged with given value", t, func() {
+		key := tag.MustNewKey("util_test")
+		v, ok := tag.FromContext(TagContext(key, "value")).Value(key)
+		So(ok, ShouldBeTrue)
+		So(v, ShouldEqual, "value")
+	})
+}